@@ -0,0 +1,264 @@
+// Package analyze checks query groups for common SQL anti-patterns.
+package analyze
+
+import (
+	"strconv"
+	"strings"
+
+	"slowlog-tui/sqlparse"
+	"slowlog-tui/types"
+)
+
+// Rule is one anti-pattern check
+type Rule struct {
+	Name   string // key in the [lint] table of the config file
+	Badge  string // short marker for the table
+	Title  string
+	Advice string // why it hurts and what to do instead
+
+	// check returns the details of the problems found, nil if there are none; an
+	// empty slice is a finding without details
+	check func(s *statement, g types.GroupedQuery) []string
+}
+
+// Finding is a rule that matched a group
+type Finding struct {
+	Rule   *Rule
+	Detail string // the offending parts of the statement, empty if there is nothing to quote
+}
+
+// Analyzer checks groups against the enabled rules. It keeps the parsed statement
+// of every group it has seen, so groups can be checked again cheaply whenever their
+// statistics change. It is not safe for concurrent use.
+type Analyzer struct {
+	rules      []*Rule
+	statements map[string]*statement // by digest
+}
+
+// New returns an Analyzer running every rule except the disabled ones
+func New(disabled []string) *Analyzer {
+	off := map[string]bool{}
+	for _, name := range disabled {
+		off[name] = true
+	}
+	a := &Analyzer{statements: map[string]*statement{}}
+	for _, r := range Rules {
+		if !off[r.Name] {
+			a.rules = append(a.rules, r)
+		}
+	}
+	return a
+}
+
+// Lookup returns the rule with the given name, or nil
+func Lookup(name string) *Rule {
+	for _, r := range Rules {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// RuleNames lists the names of all rules
+func RuleNames() []string {
+	names := make([]string, len(Rules))
+	for i, r := range Rules {
+		names[i] = r.Name
+	}
+	return names
+}
+
+// Check returns the findings of the enabled rules for g, in the order of Rules
+func (a *Analyzer) Check(g types.GroupedQuery) []Finding {
+	if len(a.rules) == 0 {
+		return nil
+	}
+	s := a.statements[g.Digest]
+	if s == nil {
+		s = newStatement(g.NormalizedSQL)
+		a.statements[g.Digest] = s
+	}
+	s.scanExamples(g.Examples)
+
+	var findings []Finding
+	for _, r := range a.rules {
+		details := r.check(s, g)
+		if details == nil {
+			continue
+		}
+		var unique []string
+		seen := map[string]bool{}
+		for _, d := range details {
+			if d != "" && !seen[d] {
+				seen[d] = true
+				unique = append(unique, d)
+			}
+		}
+		findings = append(findings, Finding{Rule: r, Detail: strings.Join(unique, ", ")})
+	}
+	return findings
+}
+
+// statement is a group's fingerprint, parsed once, plus what its kept executions
+// tell about the literals the fingerprint replaced
+type statement struct {
+	tokens []sqlparse.Token
+	depth  []int    // parenthesis depth of every token
+	where  [][2]int // token ranges of the WHERE clauses
+
+	scanned   map[exampleKey]bool // executions whose literals were read
+	maxInList int                 // longest IN list
+	wildcards []string            // LIKE patterns starting with a wildcard
+	maxOffset int64               // largest LIMIT offset, -1 if none was a number
+}
+
+// exampleKey identifies a kept execution; IDs alone repeat when the source is re-read
+type exampleKey struct {
+	id    int
+	start string
+}
+
+// maxWildcards bounds the LIKE patterns quoted per group
+const maxWildcards = 3
+
+// clauseEnd are the keywords that end a WHERE clause at its depth
+var clauseEnd = map[string]bool{
+	"GROUP": true, "HAVING": true, "ORDER": true, "LIMIT": true, "WINDOW": true, "UNION": true,
+	"FOR": true, "LOCK": true, "INTO": true, "ON": true, "RETURNING": true, "EXCEPT": true, "INTERSECT": true,
+}
+
+func newStatement(fingerprint string) *statement {
+	s := &statement{
+		tokens:    sqlparse.StripComments(sqlparse.Tokenize(fingerprint)),
+		scanned:   map[exampleKey]bool{},
+		maxOffset: -1,
+	}
+	t := s.tokens
+	s.depth = make([]int, len(t))
+	depth := 0
+	for i, tok := range t {
+		if tok.IsOp(")") {
+			depth--
+		}
+		s.depth[i] = depth
+		if tok.IsOp("(") {
+			depth++
+		}
+	}
+	for i, tok := range t {
+		if !tok.Is("WHERE") {
+			continue
+		}
+		end := i + 1
+		for end < len(t) && s.depth[end] >= s.depth[i] {
+			if s.depth[end] == s.depth[i] && (t[end].IsOp(";") || clauseEnd[strings.ToUpper(t[end].Text)] && sqlparse.IsKeyword(t, end)) {
+				break
+			}
+			end++
+		}
+		s.where = append(s.where, [2]int{i + 1, end})
+	}
+	return s
+}
+
+// scanExamples reads the literals of the executions not seen before
+func (s *statement) scanExamples(examples []types.SlowQuery) {
+	for _, q := range examples {
+		key := exampleKey{q.ID, q.StartTime}
+		if s.scanned[key] {
+			continue
+		}
+		s.scanned[key] = true
+		t := sqlparse.StripComments(sqlparse.Tokenize(q.SQLText))
+		for i, tok := range t {
+			switch {
+			case tok.Is("IN") && i+1 < len(t) && t[i+1].IsOp("("):
+				s.maxInList = max(s.maxInList, listLength(t, i+1))
+			case tok.Is("LIKE") && i+1 < len(t):
+				pattern, quoted := t[i+1], t[i+1].Text
+				if pattern.Is("CONCAT") && i+3 < len(t) {
+					pattern, quoted = t[i+3], "CONCAT("+t[i+3].Text+", ...)"
+				}
+				if p := stringValue(pattern); strings.HasPrefix(p, "%") || strings.HasPrefix(p, "_") {
+					s.addWildcard(quoted)
+				}
+			case tok.Is("LIMIT") && i+2 < len(t) && t[i+1].Kind == sqlparse.TokenNumber && t[i+2].IsOp(","):
+				s.maxOffset = max(s.maxOffset, number(t[i+1]))
+			case tok.Is("OFFSET") && i+1 < len(t) && t[i+1].Kind == sqlparse.TokenNumber:
+				s.maxOffset = max(s.maxOffset, number(t[i+1]))
+			}
+		}
+	}
+}
+
+func (s *statement) addWildcard(literal string) {
+	if len(s.wildcards) == maxWildcards {
+		return
+	}
+	if len(literal) > 32 {
+		literal = literal[:29] + "..."
+	}
+	for _, w := range s.wildcards {
+		if w == literal {
+			return
+		}
+	}
+	s.wildcards = append(s.wildcards, literal)
+}
+
+// enclosingParen returns the index of the '(' around tokens[i], or -1 at the top level
+func (s *statement) enclosingParen(i int) int {
+	for j := i - 1; j >= 0; j-- {
+		if s.tokens[j].IsOp("(") && s.depth[j] < s.depth[i] {
+			return j
+		}
+	}
+	return -1
+}
+
+// closingParen returns the index of the ')' matching the '(' at open, or len(tokens)
+func (s *statement) closingParen(open int) int {
+	for j := open + 1; j < len(s.tokens); j++ {
+		if s.tokens[j].IsOp(")") && s.depth[j] == s.depth[open] {
+			return j
+		}
+	}
+	return len(s.tokens)
+}
+
+// listLength counts the items of the parenthesized list at open, 0 for a subquery
+func listLength(t []sqlparse.Token, open int) int {
+	if open+1 >= len(t) || t[open+1].Is("SELECT") || t[open+1].IsOp(")") {
+		return 0
+	}
+	n, depth := 1, 0
+	for _, tok := range t[open+1:] {
+		switch {
+		case tok.IsOp("("):
+			depth++
+		case tok.IsOp(")"):
+			if depth == 0 {
+				return n
+			}
+			depth--
+		case tok.IsOp(",") && depth == 0:
+			n++
+		}
+	}
+	return n
+}
+
+// stringValue returns the text of a quoted string literal without its quotes, or ""
+func stringValue(t sqlparse.Token) string {
+	if t.Kind != sqlparse.TokenString || len(t.Text) < 2 || t.Text[0] != '\'' && t.Text[0] != '"' {
+		return ""
+	}
+	return t.Text[1 : len(t.Text)-1]
+}
+
+// number returns the value of an integer literal, 0 if it is not one
+func number(t sqlparse.Token) int64 {
+	n, _ := strconv.ParseInt(t.Text, 10, 64)
+	return n
+}
@@ -0,0 +1,283 @@
+package analyze
+
+import (
+	"fmt"
+	"strings"
+
+	"slowlog-tui/db"
+	"slowlog-tui/sqlparse"
+	"slowlog-tui/types"
+)
+
+// Limits of proposed indexes
+const (
+	maxIndexColumns = 5  // columns of a proposed index
+	textPrefix      = 64 // prefix length for TEXT and BLOB columns, which can only be indexed in part
+	maxIndexName    = 64 // MySQL identifier limit
+)
+
+// IndexAdvice is a composite index proposed for one table of a group
+type IndexAdvice struct {
+	Table    db.TableName
+	Parts    []KeyPart
+	Existing string // existing index serving the most leading parts, empty if none
+	Served   int    // leading parts Existing already serves
+	// Replaces is set when Existing is a non-unique prefix of the proposed index,
+	// which makes it redundant once the new index exists
+	Replaces  bool
+	Statement string // ALTER TABLE ... ADD INDEX, empty when Existing serves every part
+}
+
+// KeyPart is a column of a proposed index
+type KeyPart struct {
+	Column string
+	Use    sqlparse.ColumnUse
+	Prefix int  // indexed prefix length, 0 for the whole column
+	Desc   bool // sorted descending
+}
+
+func (p KeyPart) String() string {
+	s := p.Column
+	if p.Prefix > 0 {
+		s += fmt.Sprintf("(%d)", p.Prefix)
+	}
+	return s
+}
+
+// RecommendIndexes proposes one composite index per table of g from the columns
+// its statement filters, joins and sorts on: equality columns first, then the
+// first range column, then the ORDER BY columns, which is the order in which a
+// B-tree index can use them. Tables whose existing indexes already serve every
+// column get advice without a statement; tables without such columns get none.
+func RecommendIndexes(g types.GroupedQuery, tables []db.TableSchema) []IndexAdvice {
+	refs := sqlparse.Predicates(g.NormalizedSQL)
+	var advice []IndexAdvice
+	for _, t := range tables {
+		if t.Err != nil {
+			continue
+		}
+		parts := keyParts(refs, t, tables)
+		if len(parts) == 0 {
+			continue
+		}
+		a := IndexAdvice{Table: t.TableName, Parts: parts}
+		var best db.Index
+		for _, ix := range t.Indexes {
+			if n := servedParts(ix, parts); n > a.Served {
+				best, a.Existing, a.Served = ix, ix.Name, n
+			}
+		}
+		if a.Served < len(parts) {
+			followIndex(parts, best, a.Served)
+			a.Replaces = a.Existing != "" && !best.Unique && a.Served == len(best.Columns)
+			a.Statement = addIndexStatement(t.TableName, parts)
+		}
+		advice = append(advice, a)
+	}
+	return advice
+}
+
+// Benefit estimates what an index narrowing g's reads to the rows it sends could
+// save: the share of the examined rows that were not sent, and the same share of
+// the group's total query time. Both are upper bounds. ok is false for statements
+// that send no rows but change them, where rows sent say nothing.
+func Benefit(g types.GroupedQuery) (rows, seconds float64, ok bool) {
+	if g.AvgRowsExamined <= 0 || g.AvgRowsSent == 0 && g.QueryType != "SELECT" {
+		return 0, 0, false
+	}
+	rows = max(1-g.AvgRowsSent/g.AvgRowsExamined, 0)
+	return rows, rows * g.TotalQueryTime, true
+}
+
+// keyParts orders the columns of refs that belong to t for an index
+func keyParts(refs []sqlparse.ColumnRef, t db.TableSchema, tables []db.TableSchema) []KeyPart {
+	var equality, ranges, order []KeyPart
+	for _, r := range refs {
+		if !belongsTo(r, t, tables) {
+			continue
+		}
+		c, ok := t.Column(r.Name)
+		if !ok || !indexable(c.Type) {
+			continue
+		}
+		part := KeyPart{Column: c.Name, Use: r.Use, Prefix: prefixLength(c.Type), Desc: r.Desc}
+		switch r.Use {
+		case sqlparse.UseEquality:
+			equality = append(equality, part)
+		case sqlparse.UseRange:
+			ranges = append(ranges, part)
+		case sqlparse.UseOrder:
+			order = append(order, part)
+		}
+	}
+	if len(order) != countOrder(refs) {
+		order = nil // the sort spans tables or needs a column an index cannot hold
+	}
+
+	// whole columns first: a column indexed in part ends what the index can use
+	var parts []KeyPart
+	for _, whole := range []bool{true, false} {
+		for _, p := range equality {
+			if (p.Prefix == 0) == whole {
+				parts = appendPart(parts, p)
+			}
+		}
+	}
+	if len(ranges) > 0 {
+		// the index stops narrowing after the first range
+		parts = appendPart(parts, ranges[0])
+	}
+	for _, p := range order {
+		parts = appendPart(parts, p)
+	}
+	for i, p := range parts {
+		if p.Prefix > 0 {
+			parts = parts[:i+1]
+			break
+		}
+	}
+	return parts[:min(len(parts), maxIndexColumns)]
+}
+
+// appendPart adds p unless its column is already in parts
+func appendPart(parts []KeyPart, p KeyPart) []KeyPart {
+	for _, q := range parts {
+		if strings.EqualFold(q.Column, p.Column) {
+			return parts
+		}
+	}
+	return append(parts, p)
+}
+
+func countOrder(refs []sqlparse.ColumnRef) int {
+	n := 0
+	for _, r := range refs {
+		if r.Use == sqlparse.UseOrder {
+			n++
+		}
+	}
+	return n
+}
+
+// belongsTo reports whether a referenced column is a column of t. Unqualified
+// columns of statements reading several tables belong to the one table having them.
+func belongsTo(r sqlparse.ColumnRef, t db.TableSchema, tables []db.TableSchema) bool {
+	if r.Table != "" {
+		return strings.EqualFold(r.Table, t.Name) && (r.Schema == "" || strings.EqualFold(r.Schema, t.Schema))
+	}
+	owners := 0
+	for _, o := range tables {
+		if _, ok := o.Column(r.Name); ok {
+			owners++
+		}
+	}
+	_, ok := t.Column(r.Name)
+	return ok && owners == 1
+}
+
+// indexable reports whether a B-tree index can hold a column of the given type
+func indexable(dataType string) bool {
+	switch strings.ToLower(dataType) {
+	case "json", "geometry", "point", "linestring", "polygon", "multipoint", "multilinestring", "multipolygon", "geometrycollection", "geomcollection":
+		return false
+	}
+	return true
+}
+
+// prefixLength is the prefix to index of a column type that needs one, or 0
+func prefixLength(dataType string) int {
+	switch strings.ToLower(dataType) {
+	case "tinytext", "text", "mediumtext", "longtext", "tinyblob", "blob", "mediumblob", "longblob":
+		return textPrefix
+	}
+	return 0
+}
+
+// servedParts counts the leading parts an existing B-tree index serves: its first
+// columns must be the equality parts, in any order, followed by the other parts
+// in order. A unique index matched on equality alone serves them all.
+func servedParts(ix db.Index, parts []KeyPart) int {
+	if ix.Type != "BTREE" && ix.Type != "" {
+		return 0
+	}
+	equality := 0
+	for equality < len(parts) && parts[equality].Use == sqlparse.UseEquality {
+		equality++
+	}
+	n := 0
+	for _, c := range ix.Columns {
+		if n == len(parts) || c.Name == "" {
+			break
+		}
+		if n < equality {
+			found := false
+			for _, p := range parts[:equality] {
+				found = found || strings.EqualFold(p.Column, c.Name)
+			}
+			if !found {
+				break
+			}
+		} else if !strings.EqualFold(parts[n].Column, c.Name) {
+			break
+		}
+		n++
+		if c.SubPart > 0 {
+			break // a prefix ends what the index can use
+		}
+	}
+	if ix.Unique && n == len(ix.Columns) && n <= equality {
+		return len(parts) // a unique lookup finds at most one row
+	}
+	return n
+}
+
+// followIndex reorders the first served equality parts like the columns of ix, so
+// the proposed index starts with ix and can replace it
+func followIndex(parts []KeyPart, ix db.Index, served int) {
+	for k := 0; k < served && k < len(ix.Columns); k++ {
+		for j := k; j < len(parts) && parts[j].Use == sqlparse.UseEquality; j++ {
+			if strings.EqualFold(parts[j].Column, ix.Columns[k].Name) {
+				parts[k], parts[j] = parts[j], parts[k]
+				break
+			}
+		}
+	}
+}
+
+// addIndexStatement renders the ALTER TABLE adding an index on parts. Descending
+// parts are marked only when the sort mixes directions: an index read backwards
+// serves a sort that is descending throughout.
+func addIndexStatement(t db.TableName, parts []KeyPart) string {
+	asc, desc := false, false
+	for _, p := range parts {
+		if p.Use == sqlparse.UseOrder {
+			asc, desc = asc || !p.Desc, desc || p.Desc
+		}
+	}
+	names := make([]string, len(parts))
+	columns := make([]string, len(parts))
+	for i, p := range parts {
+		names[i] = strings.ToLower(p.Column)
+		columns[i] = quoteIdent(p.Column)
+		if p.Prefix > 0 {
+			columns[i] += fmt.Sprintf("(%d)", p.Prefix)
+		}
+		if asc && desc && p.Desc {
+			columns[i] += " DESC"
+		}
+	}
+	name := "idx_" + strings.Join(names, "_")
+	if len(name) > maxIndexName {
+		name = name[:maxIndexName]
+	}
+	table := quoteIdent(t.Name)
+	if t.Schema != "" {
+		table = quoteIdent(t.Schema) + "." + table
+	}
+	return fmt.Sprintf("ALTER TABLE %s ADD INDEX %s (%s);", table, quoteIdent(name), strings.Join(columns, ", "))
+}
+
+// quoteIdent quotes a name with backticks for use in a statement
+func quoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
@@ -0,0 +1,337 @@
+package analyze
+
+import (
+	"fmt"
+	"strings"
+
+	"slowlog-tui/sqlparse"
+	"slowlog-tui/types"
+)
+
+// Thresholds of the rules that depend on sizes
+const (
+	largeReadRows = 10000 // average rows sent above which a SELECT should have a LIMIT
+	hugeInList    = 1000  // IN list length flagged as huge
+)
+
+// Rules lists every rule, in the order findings are reported
+var Rules = []*Rule{
+	{
+		Name: "select_star", Badge: "SELECT*", Title: "SELECT *",
+		Advice: "reads and sends every column and rules out covering indexes; list the columns you need",
+		check:  selectStar,
+	},
+	{
+		Name: "leading_wildcard", Badge: "%LIKE", Title: "Leading wildcard LIKE",
+		Advice: "a pattern starting with % or _ cannot use an index; consider a FULLTEXT index or a reversed column",
+		check:  leadingWildcard,
+	},
+	{
+		Name: "order_by_rand", Badge: "RAND", Title: "ORDER BY RAND()",
+		Advice: "sorts every matching row to pick a few; pick random ids in the application instead",
+		check:  orderByRand,
+	},
+	{
+		Name: "function_on_column", Badge: "FUNC", Title: "Function on a column in WHERE",
+		Advice: "wrapping an indexed column in a function prevents index lookups; compare the bare column against a computed value",
+		check:  functionOnColumn,
+	},
+	{
+		Name: "or_across_columns", Badge: "OR", Title: "OR across columns",
+		Advice: "one index cannot serve conditions on different columns joined by OR; consider a UNION of indexed queries",
+		check:  orAcrossColumns,
+	},
+	{
+		Name: "unbounded_read", Badge: "NOLIMIT", Title: "Large read without LIMIT",
+		Advice: "the whole result is sent to the client; add a LIMIT or paginate by key",
+		check:  unboundedRead,
+	},
+	{
+		Name: "implicit_cross_join", Badge: "CROSS", Title: "Implicit cross join",
+		Advice: "comma-separated tables form a cross join that only the WHERE clause narrows; write explicit JOIN ... ON",
+		check:  implicitCrossJoin,
+	},
+	{
+		Name: "not_in_subquery", Badge: "NOT IN", Title: "NOT IN with a subquery",
+		Advice: "matches nothing when the subquery returns a NULL and often runs as a dependent subquery; use NOT EXISTS or an anti-join",
+		check:  notInSubquery,
+	},
+	{
+		Name: "huge_in_list", Badge: "IN>1k", Title: "Huge IN list",
+		Advice: "long lists are expensive to parse and optimize; load the values into a temporary table and join",
+		check:  hugeInListRule,
+	},
+	{
+		Name: "offset_pagination", Badge: "OFFSET", Title: "OFFSET pagination",
+		Advice: "the server reads and discards every skipped row; paginate by key (WHERE id > last_seen ORDER BY id LIMIT n)",
+		check:  offsetPagination,
+	},
+}
+
+// selectStar finds * and t.* in select lists, except in EXISTS subqueries
+func selectStar(s *statement, g types.GroupedQuery) []string {
+	t := s.tokens
+	var found []string
+	for i := 1; i < len(t); i++ {
+		if !t[i].IsOp("*") {
+			continue
+		}
+		prev := t[i-1]
+		detail := "*"
+		switch {
+		case prev.Is("SELECT") || prev.Is("DISTINCT") || prev.Is("ALL") || prev.IsOp(","):
+		case prev.IsOp(".") && i >= 2 && t[i-2].IsIdent():
+			detail = t[i-2].Text + ".*"
+		default:
+			continue
+		}
+		if p := s.enclosingParen(i); p > 0 && t[p-1].Is("EXISTS") {
+			continue
+		}
+		found = append(found, detail)
+	}
+	return found
+}
+
+// leadingWildcard reports the LIKE patterns of the kept executions that start with a wildcard
+func leadingWildcard(s *statement, g types.GroupedQuery) []string {
+	if len(s.wildcards) == 0 {
+		return nil
+	}
+	found := make([]string, len(s.wildcards))
+	for i, w := range s.wildcards {
+		found[i] = "LIKE " + w
+	}
+	return found
+}
+
+func orderByRand(s *statement, g types.GroupedQuery) []string {
+	t := s.tokens
+	for i := 0; i+2 < len(t); i++ {
+		if t[i].Is("ORDER") && t[i+1].Is("BY") && t[i+2].Is("RAND") {
+			return []string{}
+		}
+	}
+	return nil
+}
+
+// functionOnColumn finds function calls on indexed-looking columns that are compared in a WHERE clause
+func functionOnColumn(s *statement, g types.GroupedQuery) []string {
+	t := s.tokens
+	var found []string
+	for _, w := range s.where {
+		for i := w[0]; i+1 < w[1]; i++ {
+			if t[i].Kind != sqlparse.TokenWord || !sqlparse.IsFunction(t[i].Text) || !t[i+1].IsOp("(") {
+				continue
+			}
+			end := s.closingParen(i + 1)
+			compared := i > 0 && isComparison(t[i-1]) || end+1 < len(t) && (isComparison(t[end+1]) || t[end+1].Is("IS") ||
+				t[end+1].Is("IN") || t[end+1].Is("LIKE") || t[end+1].Is("BETWEEN") || t[end+1].Is("NOT"))
+			if !compared {
+				continue
+			}
+			for j := i + 2; j < end; j++ {
+				if isColumn(t, j) && looksIndexed(t[j].Text) {
+					found = append(found, sqlparse.Render(t[i:min(end+1, len(t))]))
+					break
+				}
+			}
+			i = end
+		}
+	}
+	return found
+}
+
+// orFrame collects the columns of the OR-ed conditions inside one pair of parentheses
+type orFrame struct {
+	columns  []string // first column of every condition before the current one
+	current  string   // first column of the current condition
+	hasOr    bool
+	subquery bool
+}
+
+// orAcrossColumns finds OR chains in WHERE clauses whose conditions test different columns
+func orAcrossColumns(s *statement, g types.GroupedQuery) []string {
+	t := s.tokens
+	var found []string
+	finish := func(f *orFrame) {
+		if !f.hasOr {
+			return
+		}
+		var distinct []string
+		seen := map[string]bool{}
+		for _, c := range append(f.columns, f.current) {
+			if key := strings.ToLower(c); c != "" && !seen[key] {
+				seen[key] = true
+				distinct = append(distinct, c)
+			}
+		}
+		if len(distinct) > 1 {
+			found = append(found, strings.Join(distinct, " OR "))
+		}
+	}
+	for _, w := range s.where {
+		frames := []*orFrame{{}}
+		for i := w[0]; i < w[1]; i++ {
+			top := frames[len(frames)-1]
+			switch {
+			case t[i].IsOp("("):
+				frames = append(frames, &orFrame{subquery: i+1 < len(t) && t[i+1].Is("SELECT")})
+			case t[i].IsOp(")"):
+				if len(frames) > 1 {
+					finish(top)
+					frames = frames[:len(frames)-1]
+				}
+			case t[i].Is("OR") || t[i].IsOp("||"):
+				top.columns = append(top.columns, top.current)
+				top.current = ""
+				top.hasOr = true
+			case isColumn(t, i):
+				// the column also starts the conditions of the enclosing parentheses
+				for k := len(frames) - 1; k >= 0 && frames[k].current == ""; k-- {
+					frames[k].current = t[i].Text
+					if frames[k].subquery {
+						break
+					}
+				}
+			}
+		}
+		for k := len(frames) - 1; k >= 0; k-- {
+			finish(frames[k])
+		}
+	}
+	return found
+}
+
+// unboundedRead flags SELECTs sending many rows without a top-level LIMIT
+func unboundedRead(s *statement, g types.GroupedQuery) []string {
+	if g.QueryType != "SELECT" || g.AvgRowsSent < largeReadRows {
+		return nil
+	}
+	for i, tok := range s.tokens {
+		if tok.Is("LIMIT") && s.depth[i] == 0 {
+			return nil
+		}
+	}
+	return []string{fmt.Sprintf("%.0f rows sent on average", g.AvgRowsSent)}
+}
+
+// fromListEnd are the keywords that end the table list of a FROM clause
+var fromListEnd = map[string]bool{
+	"WHERE": true, "GROUP": true, "HAVING": true, "ORDER": true, "LIMIT": true, "UNION": true, "ON": true,
+	"USING": true, "WINDOW": true, "FOR": true, "LOCK": true, "INTO": true, "JOIN": true, "STRAIGHT_JOIN": true,
+	"INNER": true, "LEFT": true, "RIGHT": true, "CROSS": true, "NATURAL": true, "EXCEPT": true, "INTERSECT": true,
+}
+
+// implicitCrossJoin finds FROM clauses listing tables separated by commas
+func implicitCrossJoin(s *statement, g types.GroupedQuery) []string {
+	t := s.tokens
+	var found []string
+	for i, tok := range t {
+		if !tok.Is("FROM") {
+			continue
+		}
+		// FROM inside EXTRACT(), TRIM() or SUBSTRING() is not a clause
+		if p := s.enclosingParen(i); p > 0 && t[p-1].Kind == sqlparse.TokenWord && sqlparse.IsFunction(t[p-1].Text) {
+			continue
+		}
+		comma, end := false, i+1
+		for ; end < len(t) && s.depth[end] >= s.depth[i]; end++ {
+			if s.depth[end] > s.depth[i] {
+				continue
+			}
+			if t[end].IsOp(";") || t[end].Kind == sqlparse.TokenWord && fromListEnd[strings.ToUpper(t[end].Text)] {
+				break
+			}
+			comma = comma || t[end].IsOp(",")
+		}
+		// DELETE FROM t1, t2 USING ... lists delete targets
+		if !comma || end < len(t) && t[end].Is("USING") {
+			continue
+		}
+		detail := sqlparse.Render(t[i+1 : end])
+		if end >= len(t) || !t[end].Is("WHERE") {
+			detail += " without WHERE"
+		}
+		found = append(found, detail)
+	}
+	return found
+}
+
+func notInSubquery(s *statement, g types.GroupedQuery) []string {
+	t := s.tokens
+	for i := 0; i+3 < len(t); i++ {
+		if t[i].Is("NOT") && t[i+1].Is("IN") && t[i+2].IsOp("(") && t[i+3].Is("SELECT") {
+			return []string{}
+		}
+	}
+	return nil
+}
+
+func hugeInListRule(s *statement, g types.GroupedQuery) []string {
+	if s.maxInList < hugeInList {
+		return nil
+	}
+	return []string{fmt.Sprintf("IN list of %d values", s.maxInList)}
+}
+
+// offsetPagination flags LIMIT offset, n and LIMIT n OFFSET offset, unless every
+// kept execution starts at offset 0
+func offsetPagination(s *statement, g types.GroupedQuery) []string {
+	t := s.tokens
+	found := false
+	for i := range t {
+		if t[i].Is("OFFSET") && sqlparse.IsKeyword(t, i) || t[i].Is("LIMIT") && i+2 < len(t) && t[i+1].Kind == sqlparse.TokenPlaceholder && t[i+2].IsOp(",") {
+			found = true
+		}
+	}
+	switch {
+	case !found || s.maxOffset == 0:
+		return nil
+	case s.maxOffset > 0:
+		return []string{fmt.Sprintf("offset up to %d", s.maxOffset)}
+	}
+	return []string{}
+}
+
+func isComparison(t sqlparse.Token) bool {
+	if t.Kind != sqlparse.TokenOperator {
+		return false
+	}
+	switch t.Text {
+	case "=", "<>", "!=", "<", ">", "<=", ">=", "<=>":
+		return true
+	}
+	return false
+}
+
+// isColumn reports whether tokens[i] names a column: an identifier that is not
+// called as a function and not followed by a '.' (then it qualifies the column)
+func isColumn(t []sqlparse.Token, i int) bool {
+	if !t[i].IsIdent() || sqlparse.IsKeyword(t, i) || sqlparse.IsFunction(t[i].Text) && i+1 < len(t) && t[i+1].IsOp("(") {
+		return false
+	}
+	return i+1 >= len(t) || !t[i+1].IsOp(".") && !t[i+1].IsOp("(")
+}
+
+// indexedSuffixes and indexedNames are column names that usually carry an index
+var (
+	indexedSuffixes = []string{"_id", "_at", "_on", "_date", "_time", "_ts", "_key", "_code", "_uuid"}
+	indexedNames    = map[string]bool{
+		"id": true, "uuid": true, "date": true, "created": true, "updated": true, "timestamp": true,
+		"email": true, "username": true, "status": true, "code": true, "sku": true,
+	}
+)
+
+func looksIndexed(column string) bool {
+	c := strings.ToLower(column)
+	if indexedNames[c] {
+		return true
+	}
+	for _, suffix := range indexedSuffixes {
+		if strings.HasSuffix(c, suffix) {
+			return true
+		}
+	}
+	return false
+}
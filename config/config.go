@@ -0,0 +1,340 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"slowlog-tui/analyze"
+	"slowlog-tui/db"
+	"slowlog-tui/types"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// envPrefix is prepended to the upper-cased setting names to form env var names (GOSLOW_HOST, ...)
+const envPrefix = "GOSLOW_"
+
+// connKeys lists the connection settings, in the form used by flags.
+// Config files and env vars use the same names with '_' instead of '-'.
+var connKeys = []string{"host", "port", "user", "password", "socket", "database", "tls", "tls-ca", "tls-cert", "tls-key"}
+
+// Config is the resolved runtime configuration
+type Config struct {
+	Conn        db.ConnOptions
+	AskPassword bool           // prompt for the password on the terminal
+	Profile     string         // profile taken from the config file, if any
+	Path        string         // config file that was read, if any
+	File        string         // slow query log or JSONL fixture read by SourceFile/SourceJSONL
+	Source      string         // one of the Source* constants
+	Load        db.LoadOptions // time range and example limits
+	Lint        []string       // analyze rules switched off in the [lint] table of the config file
+}
+
+// Sources selectable with -source
+const (
+	SourceSlowLog    = "slow_log"   // mysql.slow_log table
+	SourcePerfSchema = "perfschema" // performance_schema statement digests
+	SourceFile       = "file"       // slow query log file
+	SourceJSONL      = "jsonl"      // JSON Lines fixture of slow_log rows
+)
+
+// Defaults returns the connection settings used when nothing else is configured
+func Defaults() db.ConnOptions {
+	return db.ConnOptions{
+		Host:     "127.0.0.1",
+		Port:     3306,
+		User:     "root",
+		Database: "mysql",
+	}
+}
+
+// DefaultPath returns the config file location used when --config is not given
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "goslow", "config.toml")
+}
+
+// Load resolves the configuration from command-line args, environment and config file.
+// Precedence is flags > GOSLOW_* env vars > config file profile > mysql option
+// files (~/.my.cnf, ~/.mylogin.cnf) > MYSQL_* env vars > defaults.
+func Load(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("goSlow", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goSlow [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	file := fs.String("file", "", "read a slow query log file instead of the mysql.slow_log table")
+	source := fs.String("source", "", "slow_log, perfschema, file or jsonl (default slow_log, or file/jsonl with -file)")
+	configPath := fs.String("config", "", "config file (default "+DefaultPath()+")")
+	profile := fs.String("profile", "", "named connection profile from the config file")
+	since := fs.String("since", "", "only read queries started at or after this time (e.g. 2h, 7d, \"2024-01-02 15:04\")")
+	until := fs.String("until", "", "only read queries started before this time (same forms as -since)")
+	maxExamples := fs.Int("examples", db.DefaultMaxExamples, "examples kept per query group for each of: random sample, slowest, most recent (0 keeps all)")
+	watch := fs.Duration("watch", 0, "keep polling the source for new queries at this interval (e.g. 5s)")
+	askPassword := fs.Bool("p", false, "prompt for the password")
+	noDefaults := fs.Bool("no-defaults", false, "do not read mysql option files or MYSQL_* env vars")
+	defaultsFile := fs.String("defaults-file", "", "read only this mysql option file")
+	loginPath := fs.String("login-path", "", "option group to read from the option files and ~/.mylogin.cnf")
+	fs.String("host", "", "server host")
+	fs.Int("port", 0, "server TCP port")
+	fs.String("user", "", "user name")
+	fs.String("socket", "", "unix socket path (overrides host/port)")
+	fs.String("database", "", "default database")
+	fs.String("tls", "", "TLS mode: true, false, skip-verify or preferred")
+	fs.String("tls-ca", "", "CA certificate file")
+	fs.String("tls-cert", "", "client certificate file")
+	fs.String("tls-key", "", "client key file")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	if *source == "" {
+		switch {
+		case strings.HasSuffix(*file, ".jsonl"):
+			*source = SourceJSONL
+		case *file != "":
+			*source = SourceFile
+		default:
+			*source = SourceSlowLog
+		}
+	}
+	switch *source {
+	case SourceSlowLog, SourcePerfSchema:
+	case SourceFile, SourceJSONL:
+		if *file == "" {
+			return nil, fmt.Errorf("-source %s needs -file", *source)
+		}
+	default:
+		return nil, fmt.Errorf("unknown source %q", *source)
+	}
+
+	now := time.Now()
+	var tr types.TimeRange
+	var err error
+	if tr.Since, err = types.ParseTimeBound(*since, now); err != nil {
+		return nil, fmt.Errorf("-since: %v", err)
+	}
+	if tr.Until, err = types.ParseTimeBound(*until, now); err != nil {
+		return nil, fmt.Errorf("-until: %v", err)
+	}
+	if !tr.Since.IsZero() && !tr.Until.IsZero() && !tr.Since.Before(tr.Until) {
+		return nil, fmt.Errorf("-since must be before -until")
+	}
+	if *watch < 0 {
+		return nil, fmt.Errorf("-watch must not be negative")
+	}
+	if *maxExamples < 0 {
+		return nil, fmt.Errorf("-examples must not be negative")
+	}
+
+	flagValues := map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		if slices.Contains(connKeys, f.Name) {
+			flagValues[f.Name] = f.Value.String()
+		}
+	})
+
+	cfg := &Config{Conn: Defaults(), AskPassword: *askPassword, File: *file, Source: *source,
+		Load: db.LoadOptions{Range: tr, MaxExamples: *maxExamples, Watch: *watch}}
+
+	// mysql client option files and MYSQL_* env vars
+	if !*noDefaults {
+		client, err := db.ClientOptions(*defaultsFile, *loginPath)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Conn = cfg.Conn.Merge(db.EnvOptions()).Merge(client)
+	}
+
+	// Config file
+	path := firstNonEmpty(*configPath, os.Getenv(envPrefix+"CONFIG"))
+	explicit := path != ""
+	if !explicit {
+		path = DefaultPath()
+	}
+	if path != "" {
+		doc, err := readFile(path)
+		switch {
+		case err == nil:
+			cfg.Path = path
+			name := firstNonEmpty(*profile, os.Getenv(envPrefix+"PROFILE"), doc[""]["default_profile"])
+			values, err := doc.profile(name)
+			if err != nil {
+				return nil, err
+			}
+			cfg.Profile = name
+			if err := applyValues(&cfg.Conn, values); err != nil {
+				return nil, fmt.Errorf("%s: %v", path, err)
+			}
+			if cfg.Lint, err = disabledRules(doc["lint"]); err != nil {
+				return nil, fmt.Errorf("%s: %v", path, err)
+			}
+		case errors.Is(err, os.ErrNotExist) && !explicit:
+			// no config file is fine
+		default:
+			return nil, err
+		}
+	}
+	if cfg.Path == "" && *profile != "" {
+		return nil, fmt.Errorf("profile %q requested but no config file found", *profile)
+	}
+
+	// Environment
+	if err := applyValues(&cfg.Conn, envValues()); err != nil {
+		return nil, fmt.Errorf("environment: %v", err)
+	}
+
+	// Flags
+	if err := applyValues(&cfg.Conn, flagValues); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func readFile(path string) (document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	doc, err := parseTOML(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return doc, nil
+}
+
+// profile returns the settings of the named profile. With no name, the
+// [profiles.default] table is used if present, else the top-level keys but default_profile.
+func (d document) profile(name string) (map[string]string, error) {
+	if name == "" {
+		if values, ok := d["profiles.default"]; ok {
+			return values, nil
+		}
+		values := map[string]string{}
+		for key, v := range d[""] {
+			if key != "default_profile" {
+				values[key] = v
+			}
+		}
+		return values, nil
+	}
+	values, ok := d["profiles."+name]
+	if !ok {
+		return nil, fmt.Errorf("profile %q not found in config file", name)
+	}
+	return values, nil
+}
+
+// envValues collects GOSLOW_* connection settings from the environment
+func envValues() map[string]string {
+	values := map[string]string{}
+	for _, key := range connKeys {
+		name := envPrefix + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		if v, ok := os.LookupEnv(name); ok {
+			values[key] = v
+		}
+	}
+	return values
+}
+
+// applyValues overlays the given settings onto opts; unknown keys are an error.
+// A host or port given without a socket selects TCP, dropping the socket set by
+// lower layers such as ~/.my.cnf.
+func applyValues(opts *db.ConnOptions, values map[string]string) error {
+	tcp, socket := false, false
+	for rawKey, v := range values {
+		switch strings.ReplaceAll(rawKey, "_", "-") {
+		case "host":
+			opts.Host = v
+			tcp = true
+		case "port":
+			port, err := strconv.Atoi(v)
+			if err != nil || port <= 0 || port > 65535 {
+				return fmt.Errorf("invalid port %q", v)
+			}
+			opts.Port = port
+			tcp = true
+		case "user":
+			opts.User = v
+		case "password":
+			opts.Password = v
+		case "socket":
+			opts.Socket = v
+			socket = true
+		case "database":
+			opts.Database = v
+		case "tls":
+			opts.TLSMode = v
+		case "tls-ca":
+			opts.TLSCA = v
+		case "tls-cert":
+			opts.TLSCert = v
+		case "tls-key":
+			opts.TLSKey = v
+		default:
+			names := make([]string, len(connKeys))
+			for i, key := range connKeys {
+				names[i] = strings.ReplaceAll(key, "-", "_")
+			}
+			return fmt.Errorf("unknown setting %q (settings: %s)", rawKey, strings.Join(names, ", "))
+		}
+	}
+	if tcp && !socket {
+		opts.Socket = ""
+	}
+	return nil
+}
+
+// disabledRules returns the rules of the [lint] table set to false, such as
+// select_star = false
+func disabledRules(values map[string]string) ([]string, error) {
+	var disabled []string
+	for key, v := range values {
+		name := strings.ReplaceAll(key, "-", "_")
+		if analyze.Lookup(name) == nil {
+			return nil, fmt.Errorf("unknown lint rule %q (rules: %s)", key, strings.Join(analyze.RuleNames(), ", "))
+		}
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("lint rule %s: want true or false, got %q", key, v)
+		}
+		if !enabled {
+			disabled = append(disabled, name)
+		}
+	}
+	return disabled, nil
+}
+
+// ReadPassword prompts for a password on the terminal without echoing it
+func ReadPassword(prompt string, in *os.File, out io.Writer) (string, error) {
+	fmt.Fprint(out, prompt)
+	pw, err := term.ReadPassword(in.Fd())
+	fmt.Fprintln(out)
+	if err != nil {
+		return "", err
+	}
+	return string(pw), nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// isolate clears the environment Load reads and returns a directory for test files
+func isolate(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, key := range connKeys {
+		t.Setenv(envPrefix+envName(key), "")
+		os.Unsetenv(envPrefix + envName(key))
+	}
+	for _, name := range []string{envPrefix + "CONFIG", envPrefix + "PROFILE", "MYSQL_HOST", "MYSQL_PWD", "MYSQL_UNIX_PORT", "MYSQL_TCP_PORT"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	t.Setenv("MYSQL_TEST_LOGIN_FILE", filepath.Join(dir, "mylogin.cnf"))
+	return dir
+}
+
+func envName(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadSocket(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string
+		env        map[string]string
+		args       []string
+		wantSocket string
+		wantHost   string
+		wantPort   int
+	}{
+		{name: "option file socket", wantSocket: "/tmp/mysql.sock", wantHost: "127.0.0.1", wantPort: 3306},
+		{name: "host flag", args: []string{"-host", "db.example"}, wantHost: "db.example", wantPort: 3306},
+		{name: "port flag", args: []string{"-port", "3307"}, wantHost: "127.0.0.1", wantPort: 3307},
+		{name: "profile host", config: "host = \"db.example\"\n", wantHost: "db.example", wantPort: 3306},
+		{name: "env host", env: map[string]string{"GOSLOW_HOST": "db.example"}, wantHost: "db.example", wantPort: 3306},
+		{name: "socket and host flags", args: []string{"-host", "db.example", "-socket", "/run/mysqld.sock"},
+			wantSocket: "/run/mysqld.sock", wantHost: "db.example", wantPort: 3306},
+		{name: "profile socket below host flag", config: "socket = \"/run/mysqld.sock\"\n", args: []string{"-host", "db.example"},
+			wantHost: "db.example", wantPort: 3306},
+		{name: "profile host below socket env", config: "host = \"db.example\"\n", env: map[string]string{"GOSLOW_SOCKET": "/run/mysqld.sock"},
+			wantSocket: "/run/mysqld.sock", wantHost: "db.example", wantPort: 3306},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := isolate(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			cnf := writeFile(t, dir, "my.cnf", "[client]\nsocket=/tmp/mysql.sock\n")
+			toml := writeFile(t, dir, "config.toml", tt.config)
+			args := append([]string{"-defaults-file", cnf, "-config", toml}, tt.args...)
+			cfg, err := Load(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Conn.Socket != tt.wantSocket || cfg.Conn.Host != tt.wantHost || cfg.Conn.Port != tt.wantPort {
+				t.Errorf("Load(%q) = socket %q, host %q, port %d; want %q, %q, %d", tt.args,
+					cfg.Conn.Socket, cfg.Conn.Host, cfg.Conn.Port, tt.wantSocket, tt.wantHost, tt.wantPort)
+			}
+		})
+	}
+}
+
+func TestLoadUnknownSetting(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		args    []string
+		wantErr string // substring of the error, "" for none
+	}{
+		{name: "profile", config: "[profiles.prod]\nhots = \"db.example\"\n", args: []string{"-profile", "prod"}, wantErr: `unknown setting "hots"`},
+		{name: "top level", config: "user = \"dba\"\npasword = \"x\"\n", wantErr: `unknown setting "pasword"`},
+		{name: "default profile", config: "default_profile = \"prod\"\n[profiles.prod]\ntls_ca = \"ca.pem\"\n"},
+		{name: "top level only", config: "host = \"db.example\"\ntls_ca = \"ca.pem\"\n"},
+		{name: "non-connection flags", config: "", args: []string{"-examples", "5", "-watch", "5s"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := isolate(t)
+			toml := writeFile(t, dir, "config.toml", tt.config)
+			_, err := Load(append([]string{"-no-defaults", "-config", toml}, tt.args...))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Load: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Load: error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// document is a parsed config file: table name -> key -> value.
+// The top-level table has the empty name.
+type document map[string]map[string]string
+
+// parseTOML reads the small TOML subset goSlow config files use:
+// comments, [tables] / [dotted.tables], and key = "string" | number | bool
+func parseTOML(r io.Reader) (document, error) {
+	doc := document{"": {}}
+	table := ""
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: invalid table header %q", lineNo, line)
+			}
+			table = normalizeTableName(line[1 : len(line)-1])
+			if _, ok := doc[table]; !ok {
+				doc[table] = map[string]string{}
+			}
+			continue
+		}
+		eq := strings.Index(line, "=")
+		if eq == -1 {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		key := unquote(strings.TrimSpace(line[:eq]))
+		val, err := parseValue(strings.TrimSpace(line[eq+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNo, err)
+		}
+		doc[table][key] = val
+	}
+	return doc, scanner.Err()
+}
+
+// stripComment removes a trailing # comment that is not inside a quoted string
+func stripComment(line string) string {
+	inQuote := byte(0)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case inQuote != 0:
+			if c == '\\' && inQuote == '"' {
+				i++
+			} else if c == inQuote {
+				inQuote = 0
+			}
+		case c == '"' || c == '\'':
+			inQuote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// normalizeTableName turns `profiles . "prod db"` into `profiles.prod db`
+func normalizeTableName(name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = unquote(strings.TrimSpace(p))
+	}
+	return strings.Join(parts, ".")
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		if s[0] == '"' {
+			if u, err := strconv.Unquote(s); err == nil {
+				return u
+			}
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// parseValue returns the string form of a scalar TOML value
+func parseValue(v string) (string, error) {
+	if v == "" {
+		return "", fmt.Errorf("missing value")
+	}
+	switch v[0] {
+	case '"', '\'':
+		if len(v) < 2 || v[len(v)-1] != v[0] {
+			return "", fmt.Errorf("unterminated string %s", v)
+		}
+		return unquote(v), nil
+	case '[', '{':
+		return "", fmt.Errorf("arrays and inline tables are not supported")
+	}
+	return v, nil
+}
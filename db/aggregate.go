@@ -0,0 +1,229 @@
+package db
+
+import (
+	"sort"
+	"strconv"
+
+	"slowlog-tui/sqlparse"
+	"slowlog-tui/types"
+)
+
+// Aggregator groups slow query records by their SQL fingerprint. Records can be
+// added incrementally; Groups returns a snapshot of the current state.
+type Aggregator struct {
+	groups      map[string]*groupAcc
+	added       int
+	maxExamples int
+	trend       trendRange // of all executions, shared by the trends of all groups
+}
+
+// groupAcc accumulates the per-execution metric values of one group, from which
+// exact distribution statistics are computed
+type groupAcc struct {
+	group         types.GroupedQuery
+	queryTimes    []float64
+	lockTimes     []float64
+	rowsExamined  []float64
+	rowsSent      []float64
+	trend         trendCounts
+	examples      exampleSet
+	dirty         bool               // executions were added since the last snapshot
+	snapshot      types.GroupedQuery // as returned by the last Groups
+	snapshotTrend trendGeometry      // trend geometry of snapshot
+}
+
+// NewAggregator returns an empty Aggregator that keeps up to maxExamples sampled,
+// slowest and most recent examples per group (all of them if maxExamples is 0).
+// Statistics always cover every execution.
+func NewAggregator(maxExamples int) *Aggregator {
+	return &Aggregator{groups: make(map[string]*groupAcc), maxExamples: maxExamples}
+}
+
+// Add folds one record into its group. Records without an ID are numbered in arrival order.
+func (a *Aggregator) Add(q types.SlowQuery) {
+	a.added++
+	if q.ID == 0 {
+		q.ID = a.added
+	}
+	// SQL is formatted for display only for the examples that are kept, see exampleSet
+	q.QueryType = extractQueryType(q.SQLText)
+
+	norm := sqlparse.Fingerprint(q.SQLText)
+	acc, ok := a.groups[norm]
+	if !ok {
+		acc = &groupAcc{group: types.GroupedQuery{
+			Digest:        sqlparse.Checksum(norm),
+			NormalizedSQL: norm,
+			QueryType:     q.QueryType,
+			Tables:        referencedTables(norm),
+			Databases:     map[string]types.Share{},
+			Accounts:      map[types.Account]types.Share{},
+		}, examples: exampleSet{limit: a.maxExamples}}
+		a.groups[norm] = acc
+	}
+	g := &acc.group
+	g.Count++
+	queryTime := ParseTime(q.QueryTime)
+	acc.queryTimes = append(acc.queryTimes, queryTime)
+	acc.lockTimes = append(acc.lockTimes, ParseTime(q.LockTime))
+	acc.rowsExamined = append(acc.rowsExamined, float64(q.RowsExamined))
+	acc.rowsSent = append(acc.rowsSent, float64(q.RowsSent))
+	var start int64
+	if t, ok := types.ParseStartTime(q.StartTime); ok {
+		start = t.UnixNano()
+		if a.trend.add(start) {
+			for _, other := range a.groups {
+				other.trend.rebucket(a.trend.bucket)
+			}
+		}
+		acc.trend.add(start, a.trend.bucket, queryTime)
+	}
+	acc.dirty = true
+	addShare(g.Databases, q.DB, queryTime)
+	if q.UserHost != "" {
+		addShare(g.Accounts, types.ParseUserHost(q.UserHost), queryTime)
+	}
+	addAttributes(g, q.Attributes)
+	acc.examples.add(example{queryTime: queryTime, start: start, query: q})
+}
+
+// Len returns the number of records added so far
+func (a *Aggregator) Len() int {
+	return a.added
+}
+
+// Groups returns a copy of the current groups in the default order. Only the groups
+// that changed since the last call are computed again; the others share their
+// examples, maps and trend with the earlier snapshot, so callers must not modify them.
+func (a *Aggregator) Groups() []types.GroupedQuery {
+	geometry := a.trend.geometry()
+	result := make([]types.GroupedQuery, 0, len(a.groups))
+	for _, acc := range a.groups {
+		if acc.dirty {
+			acc.snapshot = acc.newSnapshot()
+		}
+		if acc.dirty || acc.snapshotTrend != geometry {
+			acc.trend.fill(&acc.snapshot, geometry)
+			acc.snapshotTrend = geometry
+		}
+		acc.dirty = false
+		result = append(result, acc.snapshot)
+	}
+	setImpact(result)
+	sortGroups(result)
+	return result
+}
+
+// newSnapshot computes the group's statistics and copies it, apart from its trend
+func (acc *groupAcc) newSnapshot() types.GroupedQuery {
+	g := acc.group
+	g.QueryTimeStats = computeStats(acc.queryTimes)
+	g.LockTimeStats = computeStats(acc.lockTimes)
+	g.RowsExaminedStats = computeStats(acc.rowsExamined)
+	g.RowsSentStats = computeStats(acc.rowsSent)
+	g.AvgQueryTime = g.QueryTimeStats.Avg
+	g.AvgRowsExamined = g.RowsExaminedStats.Avg
+	g.AvgRowsSent = g.RowsSentStats.Avg
+	g.TotalQueryTime = g.QueryTimeStats.Sum
+	g.Examples = acc.examples.queries()
+	if g.Attributes != nil {
+		attrs := make(map[string]types.AttrStat, len(g.Attributes))
+		for k, v := range g.Attributes {
+			attrs[k] = v
+		}
+		g.Attributes = attrs
+	}
+	g.Databases = copyShares(g.Databases)
+	g.Accounts = copyShares(g.Accounts)
+	return g
+}
+
+// setImpact fills in each group's share of the total query time
+func setImpact(groups []types.GroupedQuery) {
+	var total float64
+	for _, g := range groups {
+		total += g.TotalQueryTime
+	}
+	for i := range groups {
+		if total > 0 {
+			groups[i].ImpactPercent = groups[i].TotalQueryTime / total * 100
+		}
+	}
+}
+
+// sortGroups applies the default group order: total time spent desc, like the
+// "Response time" ranking of pt-query-digest, then count desc
+func sortGroups(groups []types.GroupedQuery) {
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].TotalQueryTime == groups[j].TotalQueryTime {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].TotalQueryTime > groups[j].TotalQueryTime
+	})
+}
+
+// unaggregatedAttrs are identifiers rather than metrics, so summing them is meaningless
+var unaggregatedAttrs = map[string]struct{}{
+	"Thread_id": {}, "Schema": {}, "InnoDB_trx_id": {}, "Last_errno": {}, "Killed": {},
+	"Log_slow_rate_type": {}, "Log_slow_rate_limit": {},
+}
+
+// addAttributes folds the extended attributes of one execution into the group totals
+func addAttributes(g *types.GroupedQuery, attrs map[string]string) {
+	for name, val := range attrs {
+		if _, skip := unaggregatedAttrs[name]; skip {
+			continue
+		}
+		var v float64
+		flag := false
+		switch val {
+		case "Yes":
+			v, flag = 1, true
+		case "No":
+			flag = true
+		default:
+			f, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				continue
+			}
+			v = f
+		}
+		if g.Attributes == nil {
+			g.Attributes = map[string]types.AttrStat{}
+		}
+		a := g.Attributes[name]
+		a.Sum += v
+		a.Count++
+		a.Flag = flag
+		g.Attributes[name] = a
+	}
+}
+
+// addShare counts one execution of queryTime seconds under key
+func addShare[K comparable](shares map[K]types.Share, key K, queryTime float64) {
+	s := shares[key]
+	s.Count++
+	s.Time += queryTime
+	shares[key] = s
+}
+
+func copyShares[K comparable](shares map[K]types.Share) map[K]types.Share {
+	out := make(map[K]types.Share, len(shares))
+	for k, v := range shares {
+		out[k] = v
+	}
+	return out
+}
+
+// referencedTables returns the tables a statement reads and writes
+func referencedTables(sqlText string) []types.TableRef {
+	refs := sqlparse.Tables(sqlText)
+	if len(refs) == 0 {
+		return nil
+	}
+	tables := make([]types.TableRef, len(refs))
+	for i, r := range refs {
+		tables[i] = types.TableRef{Schema: r.Schema, Name: r.Name, Role: string(r.Role)}
+	}
+	return tables
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// tlsConfigName is the name the custom TLS config is registered under with the driver
+const tlsConfigName = "goslow"
+
+// ConnOptions describes how to reach a MySQL server
+type ConnOptions struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Socket   string // unix socket path, takes precedence over Host/Port
+	Database string
+
+	TLSMode string // "", "false", "true", "skip-verify" or "preferred"
+	TLSCA   string // CA bundle used to verify the server certificate
+	TLSCert string // client certificate
+	TLSKey  string // client key
+}
+
+// Addr returns the network address the options point at, for display
+func (o ConnOptions) Addr() string {
+	if o.Socket != "" {
+		return o.Socket
+	}
+	return net.JoinHostPort(o.Host, strconv.Itoa(o.Port))
+}
+
+// DSN builds a go-sql-driver/mysql DSN from the options, registering a TLS config if needed
+func (o ConnOptions) DSN() (string, error) {
+	cfg := mysql.NewConfig()
+	cfg.User = o.User
+	cfg.Passwd = o.Password
+	cfg.DBName = o.Database
+	if o.Socket != "" {
+		cfg.Net = "unix"
+		cfg.Addr = o.Socket
+	} else {
+		cfg.Net = "tcp"
+		cfg.Addr = o.Addr()
+	}
+
+	if o.TLSCA != "" || o.TLSCert != "" || o.TLSKey != "" {
+		tlsCfg, err := o.tlsConfig()
+		if err != nil {
+			return "", err
+		}
+		if err := mysql.RegisterTLSConfig(tlsConfigName, tlsCfg); err != nil {
+			return "", err
+		}
+		cfg.TLSConfig = tlsConfigName
+	} else if o.TLSMode != "" {
+		switch o.TLSMode {
+		case "true", "false", "skip-verify", "preferred":
+			cfg.TLSConfig = o.TLSMode
+		default:
+			return "", fmt.Errorf("unknown TLS mode %q", o.TLSMode)
+		}
+	}
+	return cfg.FormatDSN(), nil
+}
+
+// tlsConfig loads the CA and client certificate files into a tls.Config
+func (o ConnOptions) tlsConfig() (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		InsecureSkipVerify: o.TLSMode == "skip-verify",
+	}
+	if o.Socket == "" {
+		tlsCfg.ServerName = o.Host
+	}
+	if o.TLSCA != "" {
+		pem, err := os.ReadFile(o.TLSCA)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", o.TLSCA)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if o.TLSCert != "" || o.TLSKey != "" {
+		cert, err := tls.LoadX509KeyPair(o.TLSCert, o.TLSKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
+}
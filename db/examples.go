@@ -0,0 +1,95 @@
+package db
+
+import (
+	"math/rand/v2"
+	"sort"
+
+	"slowlog-tui/types"
+)
+
+// DefaultMaxExamples is the number of examples kept per group and kind
+const DefaultMaxExamples = 10
+
+// example is a stored execution with the values its retention is decided on
+type example struct {
+	seq       int // arrival order within the group
+	queryTime float64
+	start     int64 // unix nanoseconds, 0 if unknown
+	query     types.SlowQuery
+}
+
+// exampleSet bounds the executions kept per group: a uniform reservoir sample of
+// all executions plus the slowest and the most recent ones, limit of each.
+// A limit of 0 keeps every execution.
+type exampleSet struct {
+	limit   int
+	seen    int
+	all     []example // limit == 0
+	sample  []example
+	slowest []example // ascending by query time
+	latest  []example // ascending by start time
+}
+
+func (s *exampleSet) add(e example) {
+	s.seen++
+	e.seq = s.seen
+	if s.limit <= 0 {
+		e.query.SQLText = FormatSQLForDisplay(e.query.SQLText)
+		s.all = append(s.all, e)
+		return
+	}
+	// reservoir sampling (Algorithm R)
+	if len(s.sample) < s.limit {
+		s.sample = append(s.sample, e)
+	} else if j := rand.IntN(s.seen); j < s.limit {
+		s.sample[j] = e
+	}
+	s.slowest = keepTop(s.slowest, e, s.limit, func(a, b example) bool { return a.queryTime < b.queryTime })
+	s.latest = keepTop(s.latest, e, s.limit, func(a, b example) bool { return a.start < b.start || a.start == b.start && a.seq < b.seq })
+}
+
+// keepTop inserts e into the ascending slice top and drops the smallest entry
+// once it holds more than n
+func keepTop(top []example, e example, n int, less func(a, b example) bool) []example {
+	if len(top) == n && !less(top[0], e) {
+		return top
+	}
+	i := sort.Search(len(top), func(i int) bool { return less(e, top[i]) })
+	top = append(top, example{})
+	copy(top[i+1:], top[i:])
+	top[i] = e
+	if len(top) > n {
+		copy(top, top[1:])
+		top = top[:n]
+	}
+	return top
+}
+
+// queries returns the kept executions without duplicates, in arrival order, with
+// their SQL formatted for display
+func (s *exampleSet) queries() []types.SlowQuery {
+	if s.limit <= 0 {
+		result := make([]types.SlowQuery, len(s.all))
+		for i, e := range s.all {
+			result[i] = e.query
+		}
+		return result
+	}
+	kept := map[int]example{}
+	for _, set := range [][]example{s.sample, s.slowest, s.latest} {
+		for _, e := range set {
+			kept[e.seq] = e
+		}
+	}
+	seqs := make([]int, 0, len(kept))
+	for seq := range kept {
+		seqs = append(seqs, seq)
+	}
+	sort.Ints(seqs)
+	result := make([]types.SlowQuery, len(seqs))
+	for i, seq := range seqs {
+		result[i] = kept[seq].query
+		result[i].SQLText = FormatSQLForDisplay(result[i].SQLText)
+	}
+	return result
+}
@@ -0,0 +1,299 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// PlanNode is one step of an EXPLAIN FORMAT=JSON plan: a table access, or an
+// operation such as a query block, ordering, grouping or subquery over its children
+type PlanNode struct {
+	Operation    string // JSON key of the step, e.g. "query_block", "ordering_operation", "table"
+	SelectID     int    // query blocks only
+	Cost         string // query_cost of a query block, if reported
+	Table        string
+	AccessType   string // ALL, index, range, ref, eq_ref, const...
+	PossibleKeys []string
+	Key          string
+	Rows         int64   // rows examined per scan
+	Filtered     float64 // percentage of the rows left by the table condition
+	Extra        []string
+	Children     []*PlanNode
+}
+
+// FullScan reports whether the step reads every row of its table
+func (n *PlanNode) FullScan() bool {
+	return n.AccessType == "ALL"
+}
+
+// Filesort reports whether the step sorts rows outside of an index
+func (n *PlanNode) Filesort() bool {
+	return n.Operation == "filesort" || n.hasExtra("Using filesort")
+}
+
+// Temporary reports whether the step materializes rows in a temporary table
+func (n *PlanNode) Temporary() bool {
+	return n.Operation == "temporary_table" || n.hasExtra("Using temporary")
+}
+
+func (n *PlanNode) hasExtra(s string) bool {
+	for _, e := range n.Extra {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// explainFlags are the boolean plan attributes shown as EXPLAIN's Extra column
+var explainFlags = []struct{ key, extra string }{
+	{"using_index", "Using index"},
+	{"using_filesort", "Using filesort"},
+	{"using_temporary_table", "Using temporary"},
+	{"using_MRR", "Using MRR"},
+	{"distinct", "Distinct"},
+}
+
+// Explainable reports whether statements of queryType can be explained
+func Explainable(queryType string) bool {
+	switch queryType {
+	case "SELECT", "INSERT", "REPLACE", "UPDATE", "DELETE":
+		return true
+	}
+	return false
+}
+
+// Explain runs EXPLAIN FORMAT=JSON for query with schema as default database.
+// The statement itself is not executed.
+func (s Server) Explain(ctx context.Context, schema, query string) (*PlanNode, error) {
+	var doc string
+	err := s.withSchema(ctx, schema, func(conn *sql.Conn) error {
+		return conn.QueryRowContext(ctx, "EXPLAIN FORMAT=JSON "+trimStatement(query)).Scan(&doc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ParsePlan(doc)
+}
+
+// ExplainAnalyze runs EXPLAIN ANALYZE (MySQL 8.0.18+) for query with schema as
+// default database and returns the plan tree with actual timings. This executes
+// the statement, so only SELECTs are accepted.
+func (s Server) ExplainAnalyze(ctx context.Context, schema, query string) (string, error) {
+	if extractQueryType(query) != "SELECT" {
+		return "", errors.New("EXPLAIN ANALYZE executes the statement, only SELECT is supported")
+	}
+	var tree string
+	err := s.withSchema(ctx, schema, func(conn *sql.Conn) error {
+		var version string
+		if err := conn.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version); err != nil {
+			return err
+		}
+		if v := parseServerVersion(version); v.mariaDB || !v.atLeast(8, 0, 18) {
+			return fmt.Errorf("EXPLAIN ANALYZE needs MySQL 8.0.18 or later, the server is %s", version)
+		}
+		return conn.QueryRowContext(ctx, "EXPLAIN ANALYZE "+trimStatement(query)).Scan(&tree)
+	})
+	return tree, err
+}
+
+// trimStatement drops blanks and statement terminators around a logged query
+func trimStatement(query string) string {
+	return strings.TrimRight(strings.TrimSpace(query), "; \t\r\n")
+}
+
+// ParsePlan parses the document of EXPLAIN FORMAT=JSON (MySQL or MariaDB) into
+// its tree of steps, keeping the order of the document
+func ParsePlan(doc string) (*PlanNode, error) {
+	dec := json.NewDecoder(strings.NewReader(doc))
+	dec.UseNumber()
+	v, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, fmt.Errorf("parsing plan: %w", err)
+	}
+	obj, ok := v.(jsonObject)
+	if !ok {
+		return nil, errors.New("parsing plan: not a JSON object")
+	}
+	root := &PlanNode{Operation: "plan"}
+	addPlanChildren(root, obj)
+	if len(root.Children) == 1 {
+		return root.Children[0], nil
+	}
+	return root, nil
+}
+
+// jsonObject is a decoded JSON object with its fields in document order
+type jsonObject []jsonField
+
+type jsonField struct {
+	key   string
+	value any
+}
+
+func (o jsonObject) get(key string) any {
+	for _, f := range o {
+		if f.key == key {
+			return f.value
+		}
+	}
+	return nil
+}
+
+// decodeOrdered decodes the next JSON value, objects as jsonObject
+func decodeOrdered(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		var obj jsonObject
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, jsonField{key.(string), v})
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		var arr []any
+		for dec.More() {
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err := dec.Token()
+		return arr, err
+	case json.Delim('}'), json.Delim(']'):
+		return nil, io.ErrUnexpectedEOF
+	}
+	return tok, nil
+}
+
+// newPlanNode builds the step for the object under key
+func newPlanNode(key string, obj jsonObject) *PlanNode {
+	n := &PlanNode{Operation: key}
+	if id, ok := obj.get("select_id").(json.Number); ok {
+		i, _ := id.Int64()
+		n.SelectID = int(i)
+	}
+	if cost, ok := obj.get("cost_info").(jsonObject); ok {
+		n.Cost = jsonString(cost.get("query_cost"))
+	}
+	if key == "table" {
+		n.Table = jsonString(obj.get("table_name"))
+		n.AccessType = jsonString(obj.get("access_type"))
+		n.Key = jsonString(obj.get("key"))
+		if keys, ok := obj.get("possible_keys").([]any); ok {
+			for _, k := range keys {
+				n.PossibleKeys = append(n.PossibleKeys, jsonString(k))
+			}
+		}
+		// MySQL reports rows_examined_per_scan, MariaDB rows
+		for _, name := range []string{"rows_examined_per_scan", "rows"} {
+			if v := obj.get(name); v != nil {
+				n.Rows = int64(jsonFloat(v))
+				break
+			}
+		}
+		n.Filtered = jsonFloat(obj.get("filtered"))
+		if obj.get("index_condition") != nil {
+			n.Extra = append(n.Extra, "Using index condition")
+		}
+		if obj.get("attached_condition") != nil {
+			n.Extra = append(n.Extra, "Using where")
+		}
+		if buf := jsonString(obj.get("using_join_buffer")); buf != "" {
+			n.Extra = append(n.Extra, "Using join buffer ("+buf+")")
+		}
+	}
+	for _, f := range explainFlags {
+		if obj.get(f.key) == true {
+			n.Extra = append(n.Extra, f.extra)
+		}
+	}
+	if msg := jsonString(obj.get("message")); msg != "" {
+		n.Extra = append(n.Extra, msg)
+	}
+	addPlanChildren(n, obj)
+	return n
+}
+
+// addPlanChildren adds the steps nested in obj to n: objects that are tables or
+// contain further steps, and lists of them such as nested_loop
+func addPlanChildren(n *PlanNode, obj jsonObject) {
+	for _, f := range obj {
+		switch v := f.value.(type) {
+		case jsonObject:
+			if isPlanStep(f.key, v) {
+				n.Children = append(n.Children, newPlanNode(f.key, v))
+			}
+		case []any:
+			list := &PlanNode{Operation: f.key}
+			for _, item := range v {
+				if o, ok := item.(jsonObject); ok {
+					addPlanChildren(list, o)
+				}
+			}
+			if len(list.Children) > 0 {
+				n.Children = append(n.Children, list)
+			}
+		}
+	}
+}
+
+// isPlanStep reports whether the object under key is a step of the plan
+func isPlanStep(key string, obj jsonObject) bool {
+	return key == "table" || key == "query_block" || hasPlanSteps(obj)
+}
+
+// hasPlanSteps reports whether obj nests steps rather than only holding attributes
+// such as cost_info
+func hasPlanSteps(obj jsonObject) bool {
+	for _, f := range obj {
+		switch v := f.value.(type) {
+		case jsonObject:
+			if isPlanStep(f.key, v) {
+				return true
+			}
+		case []any:
+			for _, item := range v {
+				if _, ok := item.(jsonObject); ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func jsonString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+	return ""
+}
+
+// jsonFloat reads numbers that MySQL sends as strings, like "filtered": "10.00"
+func jsonFloat(v any) float64 {
+	f, _ := strconv.ParseFloat(jsonString(v), 64)
+	return f
+}
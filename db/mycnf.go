@@ -0,0 +1,316 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxIncludeDepth guards against !include loops
+const maxIncludeDepth = 10
+
+// OptionFile holds the groups of a my.cnf-style option file: group -> option -> value.
+// Option names are lower-cased with '_' replaced by '-', as mysql does.
+type OptionFile map[string]map[string]string
+
+// DefaultOptionFiles returns the option files the mysql client reads, in order
+func DefaultOptionFiles() []string {
+	files := []string{"/etc/my.cnf", "/etc/mysql/my.cnf"}
+	if home := os.Getenv("MYSQL_HOME"); home != "" {
+		files = append(files, filepath.Join(home, "my.cnf"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		files = append(files, filepath.Join(home, ".my.cnf"))
+	}
+	return files
+}
+
+// DefaultLoginFile returns the path of the obfuscated login-path file
+func DefaultLoginFile() string {
+	if p := os.Getenv("MYSQL_TEST_LOGIN_FILE"); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".mylogin.cnf")
+}
+
+// ReadOptionFile parses a my.cnf-style file, following !include and !includedir
+func ReadOptionFile(path string) (OptionFile, error) {
+	opts := OptionFile{}
+	if err := opts.readFile(path, 0); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+func (o OptionFile) readFile(path string, depth int) error {
+	if depth > maxIncludeDepth {
+		return fmt.Errorf("%s: includes nested too deeply", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return o.parse(f, filepath.Dir(path), path, depth)
+}
+
+func (o OptionFile) parse(r io.Reader, dir, name string, depth int) error {
+	group := ""
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "!includedir"):
+			incDir := resolvePath(dir, strings.TrimSpace(strings.TrimPrefix(line, "!includedir")))
+			if err := o.readDir(incDir, depth+1); err != nil {
+				return err
+			}
+			continue
+		case strings.HasPrefix(line, "!include"):
+			incFile := resolvePath(dir, strings.TrimSpace(strings.TrimPrefix(line, "!include")))
+			if err := o.readFile(incFile, depth+1); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		case line[0] == '[':
+			end := strings.Index(line, "]")
+			if end == -1 {
+				return fmt.Errorf("%s:%d: invalid group header %q", name, lineNo, line)
+			}
+			group = strings.ToLower(strings.TrimSpace(line[1:end]))
+			continue
+		}
+		if group == "" {
+			return fmt.Errorf("%s:%d: option outside of a group", name, lineNo)
+		}
+		key, val := line, ""
+		if eq := strings.Index(line, "="); eq != -1 {
+			key = line[:eq]
+			val = parseOptionValue(strings.TrimSpace(line[eq+1:]))
+		}
+		key = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(key)), "_", "-")
+		if o[group] == nil {
+			o[group] = map[string]string{}
+		}
+		o[group][key] = val
+	}
+	return scanner.Err()
+}
+
+// readDir reads every *.cnf file of an !includedir directory in name order
+func (o OptionFile) readDir(dir string, depth int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && (strings.HasSuffix(e.Name(), ".cnf") || strings.HasSuffix(e.Name(), ".ini")) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if err := o.readFile(filepath.Join(dir, n), depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func resolvePath(dir, p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
+
+// parseOptionValue strips quotes and trailing comments from an option value
+func parseOptionValue(v string) string {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') {
+		if end := strings.IndexByte(v[1:], v[0]); end != -1 {
+			return unescapeOption(v[1 : end+1])
+		}
+	}
+	if i := strings.Index(v, " #"); i != -1 {
+		v = v[:i]
+	}
+	return unescapeOption(strings.TrimSpace(v))
+}
+
+func unescapeOption(v string) string {
+	return strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\s`, " ", `\\`, `\`).Replace(v)
+}
+
+// merge copies the groups of other over o
+func (o OptionFile) merge(other OptionFile) {
+	for group, values := range other {
+		if o[group] == nil {
+			o[group] = map[string]string{}
+		}
+		for k, v := range values {
+			o[group][k] = v
+		}
+	}
+}
+
+// LoadOptionFiles reads and merges the given option files, skipping ones that do not exist
+func LoadOptionFiles(files []string) (OptionFile, error) {
+	opts := OptionFile{}
+	for _, f := range files {
+		fileOpts, err := ReadOptionFile(f)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		opts.merge(fileOpts)
+	}
+	return opts, nil
+}
+
+// ConnOptions returns the connection settings of the given groups, later groups winning
+func (o OptionFile) ConnOptions(groups ...string) ConnOptions {
+	var c ConnOptions
+	for _, group := range groups {
+		for key, val := range o[group] {
+			switch key {
+			case "host":
+				c.Host = val
+			case "port":
+				if port, err := strconv.Atoi(val); err == nil {
+					c.Port = port
+				}
+			case "user":
+				c.User = val
+			case "password":
+				c.Password = val
+			case "socket":
+				c.Socket = val
+			case "database":
+				c.Database = val
+			case "ssl-ca":
+				c.TLSCA = val
+			case "ssl-cert":
+				c.TLSCert = val
+			case "ssl-key":
+				c.TLSKey = val
+			case "ssl-mode":
+				c.TLSMode = tlsModeFromSSLMode(val)
+			}
+		}
+	}
+	return c
+}
+
+// tlsModeFromSSLMode maps mysql's --ssl-mode values onto driver TLS modes
+func tlsModeFromSSLMode(mode string) string {
+	switch strings.ToUpper(mode) {
+	case "DISABLED":
+		return "false"
+	case "PREFERRED":
+		return "preferred"
+	case "REQUIRED":
+		return "skip-verify"
+	case "VERIFY_CA", "VERIFY_IDENTITY":
+		return "true"
+	}
+	return ""
+}
+
+// ClientOptions returns the connection settings from the mysql option files and login-path file.
+// The [client] and [goslow] groups are read from the option files, then the login path
+// (default "client") from ~/.mylogin.cnf. With a non-empty defaultsFile only that file is read.
+func ClientOptions(defaultsFile, loginPath string) (ConnOptions, error) {
+	var opts OptionFile
+	var err error
+	if defaultsFile != "" {
+		opts, err = ReadOptionFile(defaultsFile)
+	} else {
+		opts, err = LoadOptionFiles(DefaultOptionFiles())
+	}
+	if err != nil {
+		return ConnOptions{}, err
+	}
+	groups := []string{"client", "goslow"}
+	if loginPath == "" {
+		loginPath = "client"
+	} else {
+		groups = append(groups, loginPath)
+	}
+
+	conn := opts.ConnOptions(groups...)
+	if loginFile := DefaultLoginFile(); loginFile != "" {
+		login, err := ReadLoginFile(loginFile)
+		switch {
+		case err == nil:
+			conn = conn.Merge(login.ConnOptions("client", loginPath))
+		case os.IsNotExist(err):
+		default:
+			return ConnOptions{}, err
+		}
+	}
+	return conn, nil
+}
+
+// EnvOptions returns the connection settings from the MYSQL_* environment variables
+func EnvOptions() ConnOptions {
+	var c ConnOptions
+	c.Host = os.Getenv("MYSQL_HOST")
+	c.Password = os.Getenv("MYSQL_PWD")
+	c.Socket = os.Getenv("MYSQL_UNIX_PORT")
+	if port, err := strconv.Atoi(os.Getenv("MYSQL_TCP_PORT")); err == nil {
+		c.Port = port
+	}
+	return c
+}
+
+// Merge returns o with every non-zero field of over applied on top
+func (o ConnOptions) Merge(over ConnOptions) ConnOptions {
+	set := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	set(&o.Host, over.Host)
+	set(&o.User, over.User)
+	set(&o.Password, over.Password)
+	set(&o.Socket, over.Socket)
+	set(&o.Database, over.Database)
+	set(&o.TLSMode, over.TLSMode)
+	set(&o.TLSCA, over.TLSCA)
+	set(&o.TLSCert, over.TLSCert)
+	set(&o.TLSKey, over.TLSKey)
+	if over.Port != 0 {
+		o.Port = over.Port
+	}
+	return o
+}
+
+// parseOptionBytes parses option file content that did not come from disk (e.g. .mylogin.cnf)
+func parseOptionBytes(data []byte, name string) (OptionFile, error) {
+	opts := OptionFile{}
+	if err := opts.parse(bytes.NewReader(data), "", name, maxIncludeDepth); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
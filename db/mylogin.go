@@ -0,0 +1,67 @@
+package db
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+// .mylogin.cnf layout, as written by mysql_config_editor:
+// 4 unused bytes, a 20 byte key, then chunks of <uint32 LE length><AES-128-ECB ciphertext>
+const (
+	loginKeyOffset = 4
+	loginKeyLen    = 20
+)
+
+// ReadLoginFile decrypts and parses a mysql_config_editor login-path file
+func ReadLoginFile(path string) (OptionFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := decryptLoginFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return parseOptionBytes(plain, path)
+}
+
+func decryptLoginFile(data []byte) ([]byte, error) {
+	if len(data) < loginKeyOffset+loginKeyLen {
+		return nil, fmt.Errorf("file too short")
+	}
+	var key [aes.BlockSize]byte
+	for i, b := range data[loginKeyOffset : loginKeyOffset+loginKeyLen] {
+		key[i%aes.BlockSize] ^= b
+	}
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	rest := data[loginKeyOffset+loginKeyLen:]
+	for len(rest) >= 4 {
+		n := int(binary.LittleEndian.Uint32(rest[:4]))
+		rest = rest[4:]
+		if n > len(rest) || n%aes.BlockSize != 0 {
+			return nil, fmt.Errorf("corrupt chunk")
+		}
+		chunk := make([]byte, n)
+		for i := 0; i < n; i += aes.BlockSize {
+			block.Decrypt(chunk[i:i+aes.BlockSize], rest[i:i+aes.BlockSize])
+		}
+		rest = rest[n:]
+		if n > 0 {
+			// strip PKCS#7 padding
+			pad := int(chunk[n-1])
+			if pad > 0 && pad <= aes.BlockSize && pad <= n {
+				chunk = chunk[:n-pad]
+			}
+		}
+		out.Write(chunk)
+	}
+	return out.Bytes(), nil
+}
@@ -1,26 +1,32 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"log"
 	"regexp"
 	"slowlog-tui/types"
-	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 )
 
+// displayClauses are the clauses FormatSQLForDisplay starts on a new line, compiled once
+var displayClauses = func() []*regexp.Regexp {
+	var res []*regexp.Regexp
+	for _, clause := range []string{"ORDER BY", "GROUP BY", "HAVING", "LIMIT", "WHERE"} {
+		// find clause with word boundary, case-insensitive
+		res = append(res, regexp.MustCompile(`(?i)\b`+regexp.QuoteMeta(clause)+`\b`))
+	}
+	return res
+}()
+
 // FormatSQLForDisplay inserts a newline before common SQL clauses for readability, but only if not already at line start
 func FormatSQLForDisplay(sqlText string) string {
-	clauses := []string{
-		"ORDER BY", "GROUP BY", "HAVING", "LIMIT", "WHERE",
-	}
-	for _, clause := range clauses {
-		// Regex: find clause with word boundary, case-insensitive
-		pattern := `(?i)\b` + regexp.QuoteMeta(clause) + `\b`
-		re := regexp.MustCompile(pattern)
+	for _, re := range displayClauses {
 		matches := re.FindAllStringIndex(sqlText, -1)
 		if len(matches) == 0 {
 			continue
@@ -39,14 +45,102 @@ func FormatSQLForDisplay(sqlText string) string {
 	return sqlText
 }
 
+// FetchSlowQueries reads and groups the whole mysql.slow_log table
 func FetchSlowQueries(dsn string) ([]types.GroupedQuery, error) {
-	db, err := sql.Open("mysql", dsn)
+	return Load(context.Background(), SlowLogTableSource{DSN: dsn}, DefaultLoadOptions())
+}
+
+// SlowLogTableSource streams rows of the mysql.slow_log table (log_output=TABLE)
+type SlowLogTableSource struct {
+	DSN string
+}
+
+func (s SlowLogTableSource) Name() string {
+	return "mysql.slow_log"
+}
+
+func (s SlowLogTableSource) Server() Server {
+	return Server{DSN: s.DSN}
+}
+
+func (s SlowLogTableSource) Stream(ctx context.Context, tr types.TimeRange, fn func(types.SlowQuery) error) error {
+	db, err := sql.Open("mysql", s.DSN)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	where, args := startTimeWhere("start_time", tr)
+	return s.query(ctx, db, where, args, "query_time DESC", fn)
+}
+
+// Follow polls for rows logged at or after the latest start_time read so far
+func (s SlowLogTableSource) Follow(ctx context.Context, tr types.TimeRange, poll time.Duration, fn func(types.SlowQuery) error, caughtUp func()) error {
+	db, err := sql.Open("mysql", s.DSN)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer db.Close()
 
-	rows, err := db.Query(`
+	// Polls read the latest start_time again, as rows can still be logged with it,
+	// and skip the rows at that time that were read before
+	var last string
+	seen := map[string]int{} // rows read at last, by content
+	var batch map[string]int // rows at last in the current poll, nil during the first read
+	track := func(q types.SlowQuery) error {
+		if q.StartTime < last {
+			return fn(q)
+		}
+		if q.StartTime > last {
+			last, seen = q.StartTime, map[string]int{}
+			if batch != nil {
+				batch = map[string]int{}
+			}
+		}
+		key := slowLogRowKey(q)
+		if batch != nil {
+			batch[key]++
+			if batch[key] <= seen[key] {
+				return nil
+			}
+		}
+		seen[key]++
+		return fn(q)
+	}
+	where, args := startTimeWhere("start_time", tr)
+	if err := s.query(ctx, db, where, args, "query_time DESC", track); err != nil {
+		return err
+	}
+	caughtUp()
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		where, args := startTimeWhere("start_time", tr)
+		if last != "" {
+			where += " AND start_time >= ?"
+			args = append(args, last)
+		}
+		batch = map[string]int{}
+		if err := s.query(ctx, db, where, args, "start_time", track); err != nil {
+			return err
+		}
+	}
+}
+
+// slowLogRowKey identifies a slow_log row among those with the same start_time
+func slowLogRowKey(q types.SlowQuery) string {
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%d\x00%d\x00%s", q.UserHost, q.DB, q.QueryTime, q.LockTime, q.RowsExamined, q.RowsSent, q.SQLText)
+}
+
+// query reads the slow_log rows matching the extra AND conditions in where
+func (s SlowLogTableSource) query(ctx context.Context, db *sql.DB, where string, args []any, orderBy string, fn func(types.SlowQuery) error) error {
+	rows, err := db.QueryContext(ctx, `
 		SELECT
 			start_time,
 			user_host,
@@ -57,67 +151,54 @@ func FetchSlowQueries(dsn string) ([]types.GroupedQuery, error) {
 			lock_time,
 			sql_text
 		FROM mysql.slow_log
-		WHERE sql_text NOT LIKE '%CREATE TABLE%' AND sql_text NOT LIKE '%ALTER TABLE%'
-		ORDER BY query_time DESC
-		`)
+		WHERE sql_text NOT LIKE '%CREATE TABLE%' AND sql_text NOT LIKE '%ALTER TABLE%'`+where+`
+		ORDER BY `+orderBy, args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var allQueries []types.SlowQuery
-	id := 1
 	for rows.Next() {
 		var q types.SlowQuery
-		q.ID = id
 		err := rows.Scan(&q.StartTime, &q.UserHost, &q.DB, &q.QueryTime, &q.RowsExamined, &q.RowsSent, &q.LockTime, &q.SQLText)
 		if err != nil {
 			log.Println(err)
 			continue
 		}
-		q.QueryType = extractQueryType(q.SQLText)
-		q.SQLText = FormatSQLForDisplay(q.SQLText)
-		allQueries = append(allQueries, q)
-		id++
+		if err := fn(q); err != nil {
+			return err
+		}
 	}
+	return rows.Err()
+}
 
-	// Group queries by normalized SQL
-	groups := make(map[string]*types.GroupedQuery)
-	for _, q := range allQueries {
-		norm := normalizeSQL(q.SQLText)
-		fromTable := extractFromTable(norm)
-		g, ok := groups[norm]
-		if !ok {
-			g = &types.GroupedQuery{
-				NormalizedSQL: norm,
-				QueryType:     q.QueryType,
-				FromTable:     fromTable,
-			}
-			groups[norm] = g
-		}
-		g.Count++
-		g.AvgQueryTime += parseTime(q.QueryTime)
-		g.AvgRowsExamined += float64(q.RowsExamined)
-		g.AvgRowsSent += float64(q.RowsSent)
-		g.Examples = append(g.Examples, q)
+// startTimeWhere returns the AND conditions restricting column to tr, with their
+// arguments. The TIMESTAMP column is compared as Unix seconds, which do not depend
+// on the session time zone of the server.
+func startTimeWhere(column string, tr types.TimeRange) (string, []any) {
+	var where string
+	var args []any
+	if !tr.Since.IsZero() {
+		where += " AND UNIX_TIMESTAMP(" + column + ") >= ?"
+		args = append(args, tr.Since.Unix())
 	}
-	var result []types.GroupedQuery
-	for _, g := range groups {
-		if g.Count > 0 {
-			g.AvgQueryTime /= float64(g.Count)
-			g.AvgRowsExamined /= float64(g.Count)
-			g.AvgRowsSent /= float64(g.Count)
-		}
-		result = append(result, *g)
+	if !tr.Until.IsZero() {
+		where += " AND UNIX_TIMESTAMP(" + column + ") < ?"
+		args = append(args, tr.Until.Unix())
 	}
-	// Sort by count desc, then avg time desc (do this once here)
-	sort.Slice(result, func(i, j int) bool {
-		if result[i].Count == result[j].Count {
-			return result[i].AvgQueryTime > result[j].AvgQueryTime
-		}
-		return result[i].Count > result[j].Count
-	})
-	return result, nil
+	return where, args
+}
+
+// prepareQuery derives the query type and formats the SQL text for display
+func prepareQuery(q *types.SlowQuery) {
+	q.QueryType = extractQueryType(q.SQLText)
+	q.SQLText = FormatSQLForDisplay(q.SQLText)
+}
+
+// isSchemaChange reports whether the statement is DDL that the slow_log query excludes too
+func isSchemaChange(sqlText string) bool {
+	upper := strings.ToUpper(sqlText)
+	return strings.Contains(upper, "CREATE TABLE") || strings.Contains(upper, "ALTER TABLE")
 }
 
 // extractQueryType returns the first SQL keyword (uppercased) from the SQL text, ignoring comments and blanks
@@ -148,25 +229,8 @@ func extractQueryType(sqlText string) string {
 	return "OTHER"
 }
 
-// normalizeSQL replaces numbers and quoted strings with ? to group similar queries
-func normalizeSQL(sqlText string) string {
-	r := strings.NewReplacer(
-		"'", " ",
-		"\"", " ",
-	)
-	s := r.Replace(sqlText)
-	s = strings.Map(func(r rune) rune {
-		if r >= '0' && r <= '9' {
-			return ' '
-		}
-		return r
-	}, s)
-	s = strings.Join(strings.Fields(s), " ")
-	return s
-}
-
-// parseTime parses MySQL time string (e.g. 00:00:01) to seconds
-func parseTime(t string) float64 {
+// ParseTime parses a MySQL time string (e.g. 00:00:01) to seconds
+func ParseTime(t string) float64 {
 	parts := strings.Split(t, ":")
 	if len(parts) != 3 {
 		return 0
@@ -177,18 +241,3 @@ func parseTime(t string) float64 {
 	ss, _ := strconv.ParseFloat(s, 64)
 	return float64(hh)*3600 + float64(mm)*60 + ss
 }
-
-// extractFromTable extracts the first table name after FROM in a normalized SQL string
-func extractFromTable(normSQL string) string {
-	upper := strings.ToUpper(normSQL)
-	fromIdx := strings.Index(upper, " FROM ")
-	if fromIdx == -1 {
-		return ""
-	}
-	rest := normSQL[fromIdx+6:]
-	fields := strings.Fields(rest)
-	if len(fields) == 0 {
-		return ""
-	}
-	return fields[0]
-}
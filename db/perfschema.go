@@ -0,0 +1,251 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"slowlog-tui/sqlparse"
+	"slowlog-tui/types"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// picoseconds per second; performance_schema timers are in picoseconds
+const picosPerSecond = 1e12
+
+// errUnknownColumn is ER_BAD_FIELD_ERROR, returned by servers older than 8.0 for digest80Columns
+const errUnknownColumn = 1054
+
+const digestColumns = `
+	SCHEMA_NAME,
+	DIGEST,
+	DIGEST_TEXT,
+	COUNT_STAR,
+	SUM_TIMER_WAIT,
+	MIN_TIMER_WAIT,
+	MAX_TIMER_WAIT,
+	SUM_LOCK_TIME,
+	SUM_ROWS_EXAMINED,
+	SUM_ROWS_SENT,
+	SUM_NO_INDEX_USED,
+	FIRST_SEEN,
+	LAST_SEEN`
+
+// digest80Columns were added in MySQL 8.0
+const digest80Columns = `,
+	QUANTILE_95,
+	QUANTILE_99,
+	QUERY_SAMPLE_TEXT,
+	QUERY_SAMPLE_SEEN,
+	QUERY_SAMPLE_TIMER_WAIT`
+
+const digestFrom = `
+	FROM performance_schema.events_statements_summary_by_digest
+	WHERE DIGEST_TEXT IS NOT NULL AND COUNT_STAR > 0`
+
+const digestOrder = `
+	ORDER BY SUM_TIMER_WAIT DESC`
+
+// FetchDigestSummaries reads the server-side statement digests of performance_schema
+func FetchDigestSummaries(dsn string) ([]types.GroupedQuery, error) {
+	return Load(context.Background(), PerfSchemaSource{DSN: dsn}, DefaultLoadOptions())
+}
+
+// PerfSchemaSource reads performance_schema.events_statements_summary_by_digest.
+// Each digest becomes one group, identified by the server's DIGEST (as returned by
+// STATEMENT_DIGEST()), summing its rows for the different default schemas; the
+// query sample (MySQL 8.0+) of every schema is used as an example.
+type PerfSchemaSource struct {
+	DSN string
+}
+
+func (s PerfSchemaSource) Name() string {
+	return "performance_schema"
+}
+
+func (s PerfSchemaSource) Server() Server {
+	return Server{DSN: s.DSN}
+}
+
+// Stream emits the example of every digest, for consumers that want individual records
+func (s PerfSchemaSource) Stream(ctx context.Context, tr types.TimeRange, fn func(types.SlowQuery) error) error {
+	groups, err := s.Groups(ctx, tr)
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		for _, q := range g.Examples {
+			if err := fn(q); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Groups reads the digest summaries. Digests only keep totals since they were first
+// seen, so a time range selects the digests active in it (FIRST_SEEN..LAST_SEEN
+// overlaps tr) rather than filtering individual executions.
+func (s PerfSchemaSource) Groups(ctx context.Context, tr types.TimeRange) ([]types.GroupedQuery, error) {
+	db, err := sql.Open("mysql", s.DSN)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// compared as Unix seconds like startTimeWhere, whatever the session time zone
+	var where string
+	var args []any
+	if !tr.Since.IsZero() {
+		where += " AND UNIX_TIMESTAMP(LAST_SEEN) >= ?"
+		args = append(args, tr.Since.Unix())
+	}
+	if !tr.Until.IsZero() {
+		where += " AND UNIX_TIMESTAMP(FIRST_SEEN) < ?"
+		args = append(args, tr.Until.Unix())
+	}
+
+	mysql80 := true
+	rows, err := db.QueryContext(ctx, "SELECT"+digestColumns+digest80Columns+digestFrom+where+digestOrder, args...)
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) && myErr.Number == errUnknownColumn {
+		mysql80 = false
+		rows, err = db.QueryContext(ctx, "SELECT"+digestColumns+digestFrom+where+digestOrder, args...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// the table has one row per digest and default schema: sum them per digest
+	summaries := map[string]*digestSummary{}
+	var order []string
+	examples := 0
+	for rows.Next() {
+		var (
+			schema, sampleText, sampleSeen                 sql.NullString
+			digest, digestText, firstSeen, lastSeen        string
+			count, sumTimer, minTimer, maxTimer, sumLock   uint64
+			sumExamined, sumSent, sumNoIndexUsed, q95, q99 uint64
+			sampleTimer                                    sql.NullInt64
+		)
+		dest := []any{&schema, &digest, &digestText, &count, &sumTimer, &minTimer, &maxTimer, &sumLock,
+			&sumExamined, &sumSent, &sumNoIndexUsed, &firstSeen, &lastSeen}
+		if mysql80 {
+			dest = append(dest, &q95, &q99, &sampleText, &sampleSeen, &sampleTimer)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		d, ok := summaries[digest]
+		if !ok {
+			d = &digestSummary{digestText: digestText, minTimer: minTimer, firstSeen: firstSeen, lastSeen: lastSeen,
+				databases: map[string]types.Share{}}
+			summaries[digest] = d
+			order = append(order, digest)
+		}
+		d.count += count
+		d.sumTimer += sumTimer
+		d.minTimer = min(d.minTimer, minTimer)
+		d.maxTimer = max(d.maxTimer, maxTimer)
+		d.sumLock += sumLock
+		d.sumExamined += sumExamined
+		d.sumSent += sumSent
+		d.sumNoIndexUsed += sumNoIndexUsed
+		d.q95 = max(d.q95, q95)
+		d.q99 = max(d.q99, q99)
+		d.firstSeen = min(d.firstSeen, firstSeen)
+		d.lastSeen = max(d.lastSeen, lastSeen)
+		share := d.databases[schema.String]
+		d.databases[schema.String] = types.Share{Count: share.Count + int(count), Time: share.Time + seconds(sumTimer)}
+
+		examples++
+		example := types.SlowQuery{
+			ID:        examples,
+			StartTime: lastSeen,
+			DB:        schema.String,
+			QueryTime: formatTime(seconds(sumTimer) / float64(count)),
+			LockTime:  formatTime(0),
+			SQLText:   digestText,
+		}
+		if sampleText.Valid && sampleText.String != "" {
+			example.SQLText = sampleText.String
+			example.StartTime = sampleSeen.String
+			example.QueryTime = formatTime(seconds(uint64(sampleTimer.Int64)))
+		}
+		prepareQuery(&example)
+		d.examples = append(d.examples, example)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading performance_schema digests: %v", err)
+	}
+
+	result := make([]types.GroupedQuery, len(order))
+	for i, digest := range order {
+		result[i] = summaries[digest].group(digest)
+	}
+	setImpact(result)
+	sortGroups(result)
+	return result, nil
+}
+
+// digestSummary sums the rows of one digest
+type digestSummary struct {
+	digestText                                     string
+	count, sumTimer, minTimer, maxTimer, sumLock   uint64
+	sumExamined, sumSent, sumNoIndexUsed, q95, q99 uint64
+	firstSeen, lastSeen                            string
+	databases                                      map[string]types.Share
+	examples                                       []types.SlowQuery // one per schema
+}
+
+// group turns the summary into a group. performance_schema only keeps totals and
+// a few timer aggregates, so the remaining statistics stay zero; the quantiles of
+// a digest run in several schemas are the highest of them.
+func (d *digestSummary) group(digest string) types.GroupedQuery {
+	n := float64(d.count)
+	queryTime := types.Stats{
+		Min: seconds(d.minTimer),
+		Max: seconds(d.maxTimer),
+		Avg: seconds(d.sumTimer) / n,
+		P95: seconds(d.q95),
+		P99: seconds(d.q99),
+		Sum: seconds(d.sumTimer),
+	}
+	return types.GroupedQuery{
+		Digest:          digest,
+		NormalizedSQL:   sqlparse.Fingerprint(d.digestText),
+		QueryType:       extractQueryType(d.digestText),
+		Tables:          referencedTables(d.digestText),
+		Count:           int(d.count),
+		AvgQueryTime:    queryTime.Avg,
+		AvgRowsExamined: float64(d.sumExamined) / n,
+		AvgRowsSent:     float64(d.sumSent) / n,
+		TotalQueryTime:  queryTime.Sum,
+		QueryTimeStats:  queryTime,
+		LockTimeStats:   types.Stats{Avg: seconds(d.sumLock) / n, Sum: seconds(d.sumLock)},
+		RowsExaminedStats: types.Stats{
+			Avg: float64(d.sumExamined) / n,
+			Sum: float64(d.sumExamined),
+		},
+		RowsSentStats: types.Stats{
+			Avg: float64(d.sumSent) / n,
+			Sum: float64(d.sumSent),
+		},
+		FirstSeen: d.firstSeen,
+		LastSeen:  d.lastSeen,
+		Attributes: map[string]types.AttrStat{
+			"No_index_used": {Sum: float64(d.sumNoIndexUsed), Count: int(d.count), Flag: true},
+		},
+		Databases: d.databases,
+		Examples:  d.examples,
+	}
+}
+
+// seconds converts a performance_schema picosecond timer value to seconds
+func seconds(picos uint64) float64 {
+	return float64(picos) / picosPerSecond
+}
@@ -0,0 +1,210 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// TableName is a schema-qualified table name
+type TableName struct {
+	Schema string
+	Name   string
+}
+
+func (t TableName) String() string {
+	if t.Schema == "" {
+		return t.Name
+	}
+	return t.Schema + "." + t.Name
+}
+
+// TableSchema describes a table: its definition, size, columns and indexes
+type TableSchema struct {
+	TableName
+	CreateTable string
+	Engine      string
+	Rows        int64 // estimate from information_schema.TABLES
+	DataLength  int64 // bytes
+	IndexLength int64 // bytes
+	Columns     []Column
+	Indexes     []Index
+	Err         error // the table could not be described
+}
+
+// Column is a column of a table from information_schema.COLUMNS
+type Column struct {
+	Name     string
+	Type     string // DATA_TYPE: int, varchar, text, json, ...
+	Nullable bool
+}
+
+// Column returns the column with the given name, compared case-insensitively like
+// MySQL does
+func (t TableSchema) Column(name string) (Column, bool) {
+	for _, c := range t.Columns {
+		if strings.EqualFold(c.Name, name) {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+// Index is an index of a table from information_schema.STATISTICS
+type Index struct {
+	Name    string
+	Unique  bool
+	Type    string // BTREE, HASH, FULLTEXT, SPATIAL
+	Columns []IndexColumn
+}
+
+// IndexColumn is one column of an index
+type IndexColumn struct {
+	Name        string // empty for a functional key part
+	SubPart     int64  // indexed prefix length, 0 for the whole column
+	Cardinality int64  // estimated distinct values of the index up to this column
+	Nullable    bool
+}
+
+// Cardinality is the estimated number of distinct values of the whole index
+func (ix Index) Cardinality() int64 {
+	if len(ix.Columns) == 0 {
+		return 0
+	}
+	return ix.Columns[len(ix.Columns)-1].Cardinality
+}
+
+// DescribeTables reads the definition, size, columns and indexes of tables. Tables without
+// a schema are looked up in the connection's default database. Errors of single
+// tables are returned in their TableSchema.
+func (s Server) DescribeTables(ctx context.Context, tables []TableName) ([]TableSchema, error) {
+	var result []TableSchema
+	err := s.withSchema(ctx, "", func(conn *sql.Conn) error {
+		var current sql.NullString
+		if err := conn.QueryRowContext(ctx, "SELECT DATABASE()").Scan(&current); err != nil {
+			return err
+		}
+		for _, t := range tables {
+			if t.Schema == "" {
+				t.Schema = current.String
+			}
+			ts := TableSchema{TableName: t}
+			ts.Err = describeTable(ctx, conn, &ts)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			result = append(result, ts)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// describeTable fills in ts from information_schema and SHOW CREATE TABLE
+func describeTable(ctx context.Context, conn *sql.Conn, ts *TableSchema) error {
+	var engine sql.NullString
+	var rows, dataLength, indexLength sql.NullInt64
+	err := conn.QueryRowContext(ctx, `
+		SELECT ENGINE, TABLE_ROWS, DATA_LENGTH, INDEX_LENGTH
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?`, ts.Schema, ts.Name).Scan(&engine, &rows, &dataLength, &indexLength)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("table not found")
+	}
+	if err != nil {
+		return err
+	}
+	ts.Engine, ts.Rows, ts.DataLength, ts.IndexLength = engine.String, rows.Int64, dataLength.Int64, indexLength.Int64
+
+	if ts.Columns, err = readColumns(ctx, conn, ts.TableName); err != nil {
+		return err
+	}
+	if ts.Indexes, err = readIndexes(ctx, conn, ts.TableName); err != nil {
+		return err
+	}
+
+	// tables have two columns (Table, Create Table), views four (View, Create View, ...)
+	res, err := conn.QueryContext(ctx, "SHOW CREATE TABLE "+quoteIdent(ts.Schema)+"."+quoteIdent(ts.Name))
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+	cols, err := res.Columns()
+	if err != nil {
+		return err
+	}
+	if res.Next() {
+		values := make([]sql.NullString, len(cols))
+		dest := make([]any, len(cols))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := res.Scan(dest...); err != nil {
+			return err
+		}
+		if len(values) > 1 {
+			ts.CreateTable = values[1].String
+		}
+	}
+	return res.Err()
+}
+
+// readColumns reads the columns of a table in definition order
+func readColumns(ctx context.Context, conn *sql.Conn, t TableName) ([]Column, error) {
+	rows, err := conn.QueryContext(ctx, `
+		SELECT COLUMN_NAME, DATA_TYPE, IS_NULLABLE
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
+		ORDER BY ORDINAL_POSITION`, t.Schema, t.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []Column
+	for rows.Next() {
+		var c Column
+		var nullable string
+		if err := rows.Scan(&c.Name, &c.Type, &nullable); err != nil {
+			return nil, err
+		}
+		c.Nullable = nullable == "YES"
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+// readIndexes reads the indexes of a table, the primary key first
+func readIndexes(ctx context.Context, conn *sql.Conn, t TableName) ([]Index, error) {
+	rows, err := conn.QueryContext(ctx, `
+		SELECT INDEX_NAME, NON_UNIQUE, INDEX_TYPE, COALESCE(COLUMN_NAME, ''), SUB_PART, CARDINALITY, NULLABLE
+		FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
+		ORDER BY INDEX_NAME = 'PRIMARY' DESC, INDEX_NAME, SEQ_IN_INDEX`, t.Schema, t.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []Index
+	for rows.Next() {
+		var name, indexType, column, nullable string
+		var nonUnique int
+		var subPart, cardinality sql.NullInt64
+		if err := rows.Scan(&name, &nonUnique, &indexType, &column, &subPart, &cardinality, &nullable); err != nil {
+			return nil, err
+		}
+		if len(indexes) == 0 || indexes[len(indexes)-1].Name != name {
+			indexes = append(indexes, Index{Name: name, Unique: nonUnique == 0, Type: indexType})
+		}
+		ix := &indexes[len(indexes)-1]
+		ix.Columns = append(ix.Columns, IndexColumn{
+			Name:        column,
+			SubPart:     subPart.Int64,
+			Cardinality: cardinality.Int64,
+			Nullable:    nullable == "YES",
+		})
+	}
+	return indexes, rows.Err()
+}
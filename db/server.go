@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+// Server is the MySQL server a source reads from, used to inspect its queries
+type Server struct {
+	DSN string
+}
+
+// ServerSource is implemented by sources that read from a MySQL server
+type ServerSource interface {
+	Source
+	Server() Server
+}
+
+// withSchema calls fn with a connection whose default database is schema, or the
+// DSN's database if schema is empty
+func (s Server) withSchema(ctx context.Context, schema string, fn func(conn *sql.Conn) error) error {
+	db, err := sql.Open("mysql", s.DSN)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if schema != "" {
+		if _, err := conn.ExecContext(ctx, "USE "+quoteIdent(schema)); err != nil {
+			return err
+		}
+	}
+	return fn(conn)
+}
+
+// quoteIdent quotes a schema or table name for use in a statement
+func quoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// serverVersion is a parsed VERSION() string
+type serverVersion struct {
+	raw                 string
+	major, minor, patch int
+	mariaDB             bool
+}
+
+func parseServerVersion(v string) serverVersion {
+	sv := serverVersion{raw: v, mariaDB: strings.Contains(strings.ToLower(v), "mariadb")}
+	num, _, _ := strings.Cut(v, "-")
+	parts := strings.SplitN(num, ".", 3)
+	nums := []*int{&sv.major, &sv.minor, &sv.patch}
+	for i, p := range parts {
+		*nums[i], _ = strconv.Atoi(p)
+	}
+	return sv
+}
+
+// atLeast reports whether the version is major.minor.patch or later
+func (v serverVersion) atLeast(major, minor, patch int) bool {
+	if v.major != major {
+		return v.major > major
+	}
+	if v.minor != minor {
+		return v.minor > minor
+	}
+	return v.patch >= patch
+}
@@ -0,0 +1,293 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"slowlog-tui/types"
+)
+
+// startTimeLayout matches how start_time values are returned from mysql.slow_log
+const startTimeLayout = types.StartTimeLayout
+
+// maxLogLine bounds a single line of the slow log (long multi-row INSERTs)
+const maxLogLine = 64 << 20
+
+// LoadSlowLogFile parses a slow query log file (log_output=FILE) and groups its queries
+func LoadSlowLogFile(path string) ([]types.GroupedQuery, error) {
+	return Load(context.Background(), FileSource{Path: path}, DefaultLoadOptions())
+}
+
+// FileSource streams the entries of a slow query log file
+type FileSource struct {
+	Path string
+}
+
+func (s FileSource) Name() string {
+	return s.Path
+}
+
+func (s FileSource) Stream(ctx context.Context, tr types.TimeRange, fn func(types.SlowQuery) error) error {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	err = streamSlowLog(ctx, f, func(q types.SlowQuery) error {
+		if !tr.ContainsStartTime(q.StartTime) {
+			return nil
+		}
+		return fn(q)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %v", s.Path, err)
+	}
+	return nil
+}
+
+// Follow tails the log file, handling rotation. An entry is passed on once the next
+// one starts or the log has been quiet for a poll.
+func (s FileSource) Follow(ctx context.Context, tr types.TimeRange, poll time.Duration, fn func(types.SlowQuery) error, caughtUp func()) error {
+	p := slowLogParser{emit: func(q types.SlowQuery) error {
+		if !tr.ContainsStartTime(q.StartTime) {
+			return nil
+		}
+		return fn(q)
+	}}
+	err := tailLines(ctx, s.Path, poll,
+		func(line string) error {
+			p.line(line)
+			return p.err
+		},
+		func() {
+			p.flush()
+			caughtUp()
+		},
+		func() error {
+			p.flush()
+			return p.err
+		})
+	if err != nil {
+		return fmt.Errorf("%s: %v", s.Path, err)
+	}
+	return nil
+}
+
+// ParseSlowLog reads all slow log entries in the classic mysqld text format:
+//
+//	# Time: 2024-01-02T15:04:05.123456Z
+//	# User@Host: app[app] @ web1 [10.0.0.5]  Id:    42
+//	# Query_time: 2.000134  Lock_time: 0.000102 Rows_sent: 1  Rows_examined: 500000
+//	use shop;
+//	SET timestamp=1704207845;
+//	SELECT ...;
+func ParseSlowLog(r io.Reader) ([]types.SlowQuery, error) {
+	var queries []types.SlowQuery
+	err := streamSlowLog(context.Background(), r, func(q types.SlowQuery) error {
+		queries = append(queries, q)
+		return nil
+	})
+	return queries, err
+}
+
+// streamSlowLog parses slow log entries from r, calling fn for each one
+func streamSlowLog(ctx context.Context, r io.Reader, fn func(types.SlowQuery) error) error {
+	p := slowLogParser{emit: fn}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLogLine)
+	for scanner.Scan() && p.err == nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		p.line(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	p.flush()
+	return p.err
+}
+
+// slowLogParser accumulates the header and SQL lines of the entry being read
+type slowLogParser struct {
+	emit    func(types.SlowQuery) error
+	err     error // first error returned by emit
+	emitted int
+	cur     types.SlowQuery
+	sql     []string
+	inEntry bool   // a header line of the current entry has been seen
+	lastDB  string // mysqld only logs "use db;" when the database changes
+	lastTS  string // mysqld omits "# Time:" for entries in the same second
+}
+
+func (p *slowLogParser) line(line string) {
+	switch {
+	case strings.HasPrefix(line, "# Time:"):
+		p.flush()
+		p.inEntry = true
+		if ts, ok := parseLogTime(strings.TrimSpace(line[len("# Time:"):])); ok {
+			p.lastTS = ts
+		}
+	case strings.HasPrefix(line, "# User@Host:"):
+		if len(p.sql) > 0 {
+			p.flush()
+		}
+		p.inEntry = true
+		userHost := strings.TrimSpace(line[len("# User@Host:"):])
+		if i := strings.Index(userHost, "  Id:"); i != -1 {
+			userHost = strings.TrimSpace(userHost[:i])
+		}
+		p.cur.UserHost = userHost
+	case strings.HasPrefix(line, "# "):
+		if len(p.sql) > 0 {
+			p.flush()
+		}
+		p.inEntry = true
+		p.header(line[2:])
+	case isServerBanner(line):
+		p.flush()
+	case !p.inEntry:
+		// preamble or garbage between entries
+	default:
+		trimmed := strings.TrimSpace(line)
+		lower := strings.ToLower(trimmed)
+		switch {
+		case len(p.sql) == 0 && strings.HasPrefix(lower, "use ") && strings.HasSuffix(trimmed, ";"):
+			p.lastDB = strings.Trim(strings.TrimSpace(trimmed[4:len(trimmed)-1]), "`")
+		case len(p.sql) == 0 && strings.HasPrefix(lower, "set timestamp="):
+			if sec, err := strconv.ParseInt(strings.TrimSuffix(trimmed[len("set timestamp="):], ";"), 10, 64); err == nil {
+				p.cur.StartTime = time.Unix(sec, 0).Format(startTimeLayout)
+			}
+		default:
+			p.sql = append(p.sql, line)
+		}
+	}
+}
+
+// header parses "Key: value  Key: value" metric lines. Besides the standard
+// MySQL metrics, Percona (log_slow_verbosity) and MariaDB (log_slow_verbosity)
+// extended attributes such as Thread_id, Schema, Tmp_disk_tables or Full_scan
+// are kept in SlowQuery.Attributes.
+func (p *slowLogParser) header(line string) {
+	fields := strings.Fields(line)
+	for i := 0; i < len(fields); i++ {
+		if !strings.HasSuffix(fields[i], ":") || !isAttributeName(strings.TrimSuffix(fields[i], ":")) {
+			continue
+		}
+		key := strings.TrimSuffix(fields[i], ":")
+		val := ""
+		if i+1 < len(fields) && !strings.HasSuffix(fields[i+1], ":") {
+			val = fields[i+1]
+			i++
+		}
+		switch key {
+		case "Query_time":
+			p.cur.QueryTime = formatTime(parseSeconds(val))
+		case "Lock_time":
+			p.cur.LockTime = formatTime(parseSeconds(val))
+		case "Rows_sent":
+			p.cur.RowsSent, _ = strconv.Atoi(val)
+		case "Rows_examined":
+			p.cur.RowsExamined, _ = strconv.Atoi(val)
+		default:
+			if val == "" {
+				continue
+			}
+			if alias, ok := attributeAliases[key]; ok {
+				key = alias
+			}
+			if p.cur.Attributes == nil {
+				p.cur.Attributes = map[string]string{}
+			}
+			p.cur.Attributes[key] = val
+		}
+	}
+}
+
+// attributeAliases maps spelling differences between Percona and MariaDB to one name
+var attributeAliases = map[string]string{
+	"QC_Hit": "QC_hit",
+}
+
+// isAttributeName reports whether s looks like a slow log attribute name (Capitalized_words)
+func isAttributeName(s string) bool {
+	if s == "" || s[0] < 'A' || s[0] > 'Z' {
+		return false
+	}
+	for _, r := range s {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// flush finishes the current entry, if it has any SQL
+func (p *slowLogParser) flush() {
+	defer func() {
+		p.cur = types.SlowQuery{}
+		p.sql = nil
+		p.inEntry = false
+	}()
+	sqlText := strings.TrimSpace(strings.Join(p.sql, "\n"))
+	sqlText = strings.TrimSpace(strings.TrimSuffix(sqlText, ";"))
+	if sqlText == "" || isSchemaChange(sqlText) || p.err != nil {
+		return
+	}
+	p.emitted++
+	q := p.cur
+	q.ID = p.emitted
+	q.SQLText = sqlText
+	q.DB = p.lastDB
+	if schema := q.Attributes["Schema"]; schema != "" {
+		q.DB = schema
+	}
+	if q.StartTime == "" {
+		q.StartTime = p.lastTS
+	} else {
+		p.lastTS = q.StartTime
+	}
+	if q.QueryTime == "" {
+		q.QueryTime = formatTime(0)
+	}
+	if q.LockTime == "" {
+		q.LockTime = formatTime(0)
+	}
+	p.err = p.emit(q)
+}
+
+// isServerBanner reports whether the line is part of the header mysqld writes when (re)opening the log
+func isServerBanner(line string) bool {
+	return strings.Contains(line, ", Version: ") && strings.Contains(line, "started with:") ||
+		strings.HasPrefix(line, "Tcp port: ") ||
+		strings.HasPrefix(line, "Time                 Id Command")
+}
+
+// parseLogTime parses "# Time:" values: ISO 8601 (5.7+) or the old YYMMDD H:MM:SS form.
+// Times are returned in the local zone, like those derived from SET timestamp.
+func parseLogTime(s string) (string, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999", "060102 15:04:05", "060102  15:04:05"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t.Local().Format(startTimeLayout), true
+		}
+	}
+	return "", false
+}
+
+func parseSeconds(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+// formatTime formats seconds like MySQL TIME(6) values (e.g. 00:00:01.500000)
+func formatTime(sec float64) string {
+	whole := int64(sec)
+	frac := sec - float64(whole)
+	return fmt.Sprintf("%02d:%02d:%09.6f", whole/3600, (whole%3600)/60, float64(whole%60)+frac)
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"slowlog-tui/types"
+)
+
+// Source produces slow query records, e.g. from the slow_log table or a log file
+type Source interface {
+	// Name describes the source for status messages
+	Name() string
+	// Stream calls fn for every record started within tr until the source is
+	// exhausted, ctx is cancelled or fn returns an error
+	Stream(ctx context.Context, tr types.TimeRange, fn func(types.SlowQuery) error) error
+}
+
+// GroupSource is implemented by sources whose server already groups statements,
+// such as performance_schema digests. Load prefers Groups over Stream.
+type GroupSource interface {
+	Source
+	Groups(ctx context.Context, tr types.TimeRange) ([]types.GroupedQuery, error)
+}
+
+// Follower is implemented by sources that can keep delivering records as they are
+// logged, for watch mode
+type Follower interface {
+	Source
+	// Follow streams the records within tr like Stream, calls caughtUp once the
+	// existing records were read, then checks for new records every poll until ctx
+	// is cancelled
+	Follow(ctx context.Context, tr types.TimeRange, poll time.Duration, fn func(types.SlowQuery) error, caughtUp func()) error
+}
+
+// LoadOptions controls which records Load reads and how much of them it keeps
+type LoadOptions struct {
+	Range       types.TimeRange // only records started within this range
+	MaxExamples int             // examples kept per group and kind, see NewAggregator
+	Watch       time.Duration   // poll interval of watch mode, 0 to read the source once
+}
+
+// DefaultLoadOptions reads everything and keeps DefaultMaxExamples examples
+func DefaultLoadOptions() LoadOptions {
+	return LoadOptions{MaxExamples: DefaultMaxExamples}
+}
+
+// Load reads the records of a source and returns its grouped queries
+func Load(ctx context.Context, src Source, opts LoadOptions) ([]types.GroupedQuery, error) {
+	if gs, ok := src.(GroupSource); ok {
+		return gs.Groups(ctx, opts.Range)
+	}
+	agg := NewAggregator(opts.MaxExamples)
+	err := src.Stream(ctx, opts.Range, func(q types.SlowQuery) error {
+		agg.Add(q)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return agg.Groups(), nil
+}
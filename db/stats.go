@@ -0,0 +1,47 @@
+package db
+
+import (
+	"math"
+	"sort"
+
+	"slowlog-tui/types"
+)
+
+// computeStats summarizes values; percentiles use the nearest-rank method
+func computeStats(values []float64) types.Stats {
+	if len(values) == 0 {
+		return types.Stats{}
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	n := float64(len(sorted))
+	avg := sum / n
+	var sq float64
+	for _, v := range sorted {
+		sq += (v - avg) * (v - avg)
+	}
+	return types.Stats{
+		Min:    sorted[0],
+		Max:    sorted[len(sorted)-1],
+		Avg:    avg,
+		Median: percentile(sorted, 0.50),
+		P95:    percentile(sorted, 0.95),
+		P99:    percentile(sorted, 0.99),
+		StdDev: math.Sqrt(sq / n),
+		Sum:    sum,
+	}
+}
+
+// percentile returns the nearest-rank percentile p (0..1] of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
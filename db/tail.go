@@ -0,0 +1,127 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+// tailLines calls line for every line of the file at path, calls caughtUp at the
+// end of the existing content, then keeps reading appended lines every poll like
+// tail -F: a rotated file is read to its end before the new file at path is
+// opened, and a truncated file is read again from the start. idle is called
+// after a poll that found nothing new.
+func tailLines(ctx context.Context, path string, poll time.Duration, line func(string) error, caughtUp func(), idle func() error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { f.Close() }()
+	r := bufio.NewReaderSize(f, 64*1024)
+	var partial strings.Builder // last line while it has no newline yet
+
+	// readAvailable passes on the complete lines available so far
+	readAvailable := func() (bool, error) {
+		got := false
+		for {
+			if err := ctx.Err(); err != nil {
+				return got, err
+			}
+			s, err := r.ReadString('\n')
+			if s != "" {
+				got = true
+			}
+			if errors.Is(err, io.EOF) {
+				partial.WriteString(s)
+				return got, nil
+			}
+			if err != nil {
+				return got, err
+			}
+			partial.WriteString(strings.TrimSuffix(strings.TrimSuffix(s, "\n"), "\r"))
+			err = line(partial.String())
+			partial.Reset()
+			if err != nil {
+				return got, err
+			}
+		}
+	}
+	// flushPartial passes on an unterminated last line once the writer went quiet
+	flushPartial := func() error {
+		if partial.Len() == 0 {
+			return nil
+		}
+		s := partial.String()
+		partial.Reset()
+		return line(s)
+	}
+	// offset is the position up to which the file has been read
+	offset := func() int64 {
+		pos, _ := f.Seek(0, io.SeekCurrent)
+		return pos - int64(r.Buffered())
+	}
+
+	if _, err := readAvailable(); err != nil {
+		return err
+	}
+	caughtUp()
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		got, err := readAvailable()
+		if err != nil {
+			return err
+		}
+		if got {
+			continue
+		}
+
+		cur, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		fi, err := os.Stat(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			// rotated away, the new file is not there yet
+		case err != nil:
+			return err
+		case !os.SameFile(cur, fi):
+			if err := flushPartial(); err != nil {
+				return err
+			}
+			nf, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			f.Close()
+			f = nf
+			r.Reset(f)
+			continue
+		case fi.Size() < offset():
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			r.Reset(f)
+			partial.Reset()
+			continue
+		}
+		if err := flushPartial(); err != nil {
+			return err
+		}
+		if err := idle(); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"time"
+
+	"slowlog-tui/types"
+)
+
+// trendBuckets are the bucket widths a trend can use, finest first
+var trendBuckets = []time.Duration{
+	time.Minute, 5 * time.Minute, 15 * time.Minute,
+	time.Hour, 6 * time.Hour,
+	24 * time.Hour, 7 * 24 * time.Hour,
+}
+
+// maxTrendBuckets bounds the number of buckets per trend; the finest bucket width
+// that covers the whole range within it is used
+const maxTrendBuckets = 120
+
+// trendBucket picks the bucket width for a range of the given span
+func trendBucket(span time.Duration) time.Duration {
+	for _, b := range trendBuckets {
+		if span < b*maxTrendBuckets {
+			return b
+		}
+	}
+	return trendBuckets[len(trendBuckets)-1]
+}
+
+// trendRange is the time range of the executions of all groups and the bucket
+// width their trends use. The width only grows, as the range does.
+type trendRange struct {
+	first, last int64 // unix nanoseconds, 0 until an execution has a start time
+	bucket      time.Duration
+}
+
+// add extends the range to an execution at t and reports whether the trends need
+// a wider bucket
+func (r *trendRange) add(t int64) bool {
+	if r.first == 0 || t < r.first {
+		r.first = t
+	}
+	r.last = max(r.last, t)
+	bucket := trendBucket(time.Duration(r.last - r.first))
+	if bucket == r.bucket {
+		return false
+	}
+	wider := r.bucket != 0
+	r.bucket = bucket
+	return wider
+}
+
+// trendGeometry places the buckets of the trends of one snapshot
+type trendGeometry struct {
+	start  int64 // of the first bucket, unix nanoseconds
+	bucket time.Duration
+	n      int // buckets
+}
+
+func (r trendRange) geometry() trendGeometry {
+	if r.first == 0 {
+		return trendGeometry{}
+	}
+	start := time.Unix(0, r.first).Truncate(r.bucket)
+	n := int(time.Unix(0, r.last).Sub(start)/r.bucket) + 1
+	return trendGeometry{start: start.UnixNano(), bucket: r.bucket, n: n}
+}
+
+// trendCounts buckets the executions of one group as they are added. Buckets of
+// all widths start at multiples of their width, so a wider bucket holds whole
+// narrower ones.
+type trendCounts struct {
+	first, last int64               // unix nanoseconds, 0 until an execution has a start time
+	buckets     map[int64]trendCell // by bucket start, unix nanoseconds
+}
+
+type trendCell struct {
+	count int
+	time  float64 // seconds
+}
+
+// add counts an execution of queryTime seconds at t
+func (c *trendCounts) add(t int64, bucket time.Duration, queryTime float64) {
+	if c.first == 0 || t < c.first {
+		c.first = t
+	}
+	c.last = max(c.last, t)
+	if c.buckets == nil {
+		c.buckets = map[int64]trendCell{}
+	}
+	start := time.Unix(0, t).Truncate(bucket).UnixNano()
+	cell := c.buckets[start]
+	cell.count++
+	cell.time += queryTime
+	c.buckets[start] = cell
+}
+
+// rebucket merges the buckets into wider ones
+func (c *trendCounts) rebucket(bucket time.Duration) {
+	if len(c.buckets) == 0 {
+		return
+	}
+	wider := make(map[int64]trendCell, len(c.buckets))
+	for start, cell := range c.buckets {
+		start = time.Unix(0, start).Truncate(bucket).UnixNano()
+		w := wider[start]
+		w.count += cell.count
+		w.time += cell.time
+		wider[start] = w
+	}
+	c.buckets = wider
+}
+
+// fill sets the trend and first/last seen times of g, laid out by geometry
+func (c *trendCounts) fill(g *types.GroupedQuery, geometry trendGeometry) {
+	if c.first == 0 {
+		return
+	}
+	tr := types.Trend{Start: time.Unix(0, geometry.start), Bucket: geometry.bucket,
+		Counts: make([]int, geometry.n), Times: make([]float64, geometry.n)}
+	for start, cell := range c.buckets {
+		b := int(time.Duration(start-geometry.start) / geometry.bucket)
+		tr.Counts[b] += cell.count
+		tr.Times[b] += cell.time
+	}
+	g.Trend = tr
+	g.FirstSeen = time.Unix(0, c.first).Format(startTimeLayout)
+	g.LastSeen = time.Unix(0, c.last).Format(startTimeLayout)
+}
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 
+	"slowlog-tui/analyze"
+	"slowlog-tui/config"
 	"slowlog-tui/db"
 	"slowlog-tui/ui"
 
@@ -11,23 +15,65 @@ import (
 )
 
 func main() {
-	dsn := "root:test123@tcp(127.0.0.1:3306)/mysql"
-	queries, err := db.FetchSlowQueries(dsn)
-
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Println("-> Error loading config:", err)
+		os.Exit(2)
+	}
+	src, err := openSource(cfg)
 	if err != nil {
 		fmt.Println("-> Error loading slow log:", err)
 		os.Exit(1)
 	}
+	fmt.Println("-> Reading", describeSource(cfg, src))
 
-	fmt.Printf("-> Loaded %d grouped slow queries\n", len(queries))
-	if len(queries) == 0 {
-		fmt.Println("->  No slow queries found in mysql.slow_log")
-		return
-	}
-
-	model := ui.NewModel(queries)
+	model := ui.NewModel(src, cfg.Load, analyze.New(cfg.Lint))
 	if _, err := tea.NewProgram(model).Run(); err != nil {
 		fmt.Println("-> Error running TUI:", err)
 		os.Exit(1)
 	}
 }
+
+// openSource builds the data source selected by the config, prompting for a password if asked
+func openSource(cfg *config.Config) (db.Source, error) {
+	switch cfg.Source {
+	case config.SourceFile:
+		return db.FileSource{Path: cfg.File}, nil
+	case config.SourceJSONL:
+		return db.JSONLSource{Path: cfg.File}, nil
+	}
+
+	if cfg.AskPassword {
+		pw, err := config.ReadPassword("Enter password: ", os.Stdin, os.Stdout)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Conn.Password = pw
+	}
+	dsn, err := cfg.Conn.DSN()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Source == config.SourcePerfSchema {
+		return db.PerfSchemaSource{DSN: dsn}, nil
+	}
+	return db.SlowLogTableSource{DSN: dsn}, nil
+}
+
+func describeSource(cfg *config.Config, src db.Source) string {
+	name := src.Name()
+	switch cfg.Source {
+	case config.SourceSlowLog, config.SourcePerfSchema:
+		name += " on " + cfg.Conn.Addr()
+	}
+	if !cfg.Load.Range.IsZero() {
+		name += " (" + cfg.Load.Range.String() + ")"
+	}
+	if cfg.Load.Watch > 0 {
+		name += ", watching every " + cfg.Load.Watch.String()
+	}
+	return name
+}
@@ -0,0 +1,16 @@
+package sqlparse
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"strings"
+)
+
+// Checksum returns a stable 64-bit identifier for a fingerprint: the last 16 hex
+// digits of its MD5, upper-cased and prefixed with 0x. The scheme is the one of
+// pt-query-digest, but the IDs differ from its Query IDs because Fingerprint
+// renders statements differently (upper-case keywords, IN (?+)).
+func Checksum(fingerprint string) string {
+	sum := md5.Sum([]byte(fingerprint))
+	return "0x" + strings.ToUpper(hex.EncodeToString(sum[8:]))
+}
@@ -0,0 +1,210 @@
+package sqlparse
+
+import (
+	"strings"
+)
+
+// Fingerprint returns the canonical form of a statement used to group similar
+// queries, in the spirit of pt-query-digest:
+//
+//   - comments are removed and whitespace is collapsed
+//   - string, number, hex and bit literals (with their sign) become ?
+//   - IN lists of literals and multi-row VALUES collapse to (?+)
+//   - keywords and built-in function calls are upper-cased; identifiers keep their case
+//     and lose their backticks
+//
+// For example `select * from t1 where id in (1,2,3) -- x` becomes
+// "SELECT * FROM t1 WHERE id IN (?+)".
+func Fingerprint(sql string) string {
+	tokens := StripComments(Tokenize(sql))
+	for len(tokens) > 0 && tokens[len(tokens)-1].IsOp(";") {
+		tokens = tokens[:len(tokens)-1]
+	}
+	tokens = replaceLiterals(tokens)
+	tokens = collapseLists(tokens)
+	return Render(tokens)
+}
+
+// replaceLiterals turns literals into placeholders, absorbing unary signs and charset introducers
+func replaceLiterals(tokens []Token) []Token {
+	out := make([]Token, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch {
+		case t.IsLiteral():
+			out = append(out, Token{TokenPlaceholder, "?"})
+		case (t.IsOp("-") || t.IsOp("+")) && i+1 < len(tokens) && tokens[i+1].Kind == TokenNumber && isUnaryPosition(out):
+			out = append(out, Token{TokenPlaceholder, "?"})
+			i++
+		case t.Kind == TokenWord && strings.HasPrefix(t.Text, "_") && i+1 < len(tokens) && tokens[i+1].Kind == TokenString:
+			// charset introducer such as _utf8mb4'abc'
+			out = append(out, Token{TokenPlaceholder, "?"})
+			i++
+		case t.Kind == TokenWord && (IsKeyword(tokens, i) || IsFunction(t.Text) && i+1 < len(tokens) && tokens[i+1].IsOp("(")):
+			out = append(out, Token{TokenWord, strings.ToUpper(t.Text)})
+		default:
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// isUnaryPosition reports whether a sign at this point starts a literal rather than a subtraction
+func isUnaryPosition(prev []Token) bool {
+	if len(prev) == 0 {
+		return true
+	}
+	last := prev[len(prev)-1]
+	switch last.Kind {
+	case TokenOperator:
+		return last.Text != ")"
+	case TokenWord:
+		return IsReserved(last.Text)
+	}
+	return false
+}
+
+// collapseLists rewrites IN (?, ?, ...) and VALUES (...), (...) to a single (?+)
+func collapseLists(tokens []Token) []Token {
+	out := make([]Token, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		out = append(out, t)
+		isIn := t.Is("IN")
+		isValues := t.Is("VALUES") || t.Is("VALUE")
+		if (!isIn && !isValues) || i+1 >= len(tokens) || !tokens[i+1].IsOp("(") {
+			continue
+		}
+		end := matchingParen(tokens, i+1)
+		if end == -1 {
+			continue
+		}
+		if isValues {
+			// drop the additional rows: , ( ... ) , ( ... )
+			last := end
+			for last+2 < len(tokens) && tokens[last+1].IsOp(",") && tokens[last+2].IsOp("(") {
+				next := matchingParen(tokens, last+2)
+				if next == -1 {
+					break
+				}
+				last = next
+			}
+			if onlyPlaceholders(tokens[i+2 : end]) {
+				out = append(out, Token{TokenOperator, "("}, Token{TokenPlaceholder, "?+"}, Token{TokenOperator, ")"})
+			} else {
+				out = append(out, tokens[i+1:end+1]...)
+			}
+			i = last
+			continue
+		}
+		if onlyPlaceholders(tokens[i+2 : end]) {
+			out = append(out, Token{TokenOperator, "("}, Token{TokenPlaceholder, "?+"}, Token{TokenOperator, ")"})
+			i = end
+		}
+	}
+	return out
+}
+
+// matchingParen returns the index of the ')' closing the '(' at open, or -1
+func matchingParen(tokens []Token, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		switch {
+		case tokens[i].IsOp("("):
+			depth++
+		case tokens[i].IsOp(")"):
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// onlyPlaceholders reports whether a list holds nothing but placeholders, commas and row parens
+func onlyPlaceholders(tokens []Token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	for _, t := range tokens {
+		if t.Kind != TokenPlaceholder && !t.IsOp(",") && !t.IsOp("(") && !t.IsOp(")") {
+			return false
+		}
+	}
+	return true
+}
+
+// tableKeywords are followed by a table name, after which '(' opens a column list
+var tableKeywords = toSet(`INTO TABLE UPDATE JOIN FROM REPLACE INSERT`)
+
+// Render joins tokens back into SQL text with canonical spacing
+func Render(tokens []Token) string {
+	var b strings.Builder
+	for i, t := range tokens {
+		if i > 0 && needsSpace(tokens, i) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(tokenText(t))
+	}
+	return b.String()
+}
+
+func tokenText(t Token) string {
+	if t.Kind == TokenQuotedIdent && needsQuoting(t.Text) {
+		return "`" + strings.ReplaceAll(t.Text, "`", "``") + "`"
+	}
+	return t.Text
+}
+
+// needsQuoting reports whether an identifier must keep its backticks to stay valid SQL
+func needsQuoting(name string) bool {
+	if name == "" || IsReserved(name) {
+		return true
+	}
+	for i := 0; i < len(name); i++ {
+		if !isWordChar(name[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// needsSpace decides whether a space separates tokens[i-1] and tokens[i]
+func needsSpace(tokens []Token, i int) bool {
+	prev, cur := tokens[i-1], tokens[i]
+	switch {
+	case cur.IsOp(",") || cur.IsOp(")") || cur.IsOp(".") || cur.IsOp(";"):
+		return false
+	case prev.IsOp("(") || prev.IsOp("."):
+		return false
+	case cur.IsOp("("):
+		return !isCallName(tokens, i-1)
+	}
+	return true
+}
+
+// isCallName reports whether tokens[i] names a function being called by the following '('
+func isCallName(tokens []Token, i int) bool {
+	t := tokens[i]
+	if t.Kind != TokenWord {
+		return false
+	}
+	if IsFunction(t.Text) {
+		return true
+	}
+	if IsKeyword(tokens, i) {
+		return false
+	}
+	// skip a schema qualifier (db.tbl) to find the keyword before the name
+	j := i - 1
+	if j >= 1 && tokens[j].IsOp(".") {
+		j -= 2
+	}
+	if j >= 0 && tokens[j].Kind == TokenWord {
+		if _, ok := tableKeywords[strings.ToUpper(tokens[j].Text)]; ok {
+			return false
+		}
+	}
+	return true
+}
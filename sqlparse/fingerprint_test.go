@@ -0,0 +1,60 @@
+package sqlparse
+
+import "testing"
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		// literals
+		{"SELECT * FROM t WHERE name = 'abc'", "SELECT * FROM t WHERE name = ?"},
+		{"SELECT * FROM t WHERE name = \"xyz\"", "SELECT * FROM t WHERE name = ?"},
+		{"SELECT * FROM t WHERE id = 42 AND score > 1.5e3", "SELECT * FROM t WHERE id = ? AND score > ?"},
+		{"SELECT * FROM t WHERE id = -1", "SELECT * FROM t WHERE id = ?"},
+		{"SELECT * FROM t WHERE a = 0xFF AND b = b'01' AND c = _utf8mb4'x'", "SELECT * FROM t WHERE a = ? AND b = ? AND c = ?"},
+		{"SELECT a - 1 FROM t", "SELECT a - ? FROM t"},
+
+		// lists
+		{"SELECT * FROM t WHERE id IN (1,2,3)", "SELECT * FROM t WHERE id IN (?+)"},
+		{"SELECT * FROM t WHERE id IN (1, 2, 3, 4)", "SELECT * FROM t WHERE id IN (?+)"},
+		{"SELECT * FROM t WHERE id IN ('a')", "SELECT * FROM t WHERE id IN (?+)"},
+		{"SELECT * FROM t WHERE id IN (SELECT id FROM u)", "SELECT * FROM t WHERE id IN (SELECT id FROM u)"},
+		{"INSERT INTO t (a, b) VALUES (1, 'x')", "INSERT INTO t (a, b) VALUES (?+)"},
+		{"INSERT INTO t (a, b) VALUES (1, 'x'), (2, 'y'), (3, 'z')", "INSERT INTO t (a, b) VALUES (?+)"},
+
+		// comments
+		{"SELECT 1 -- trailing", "SELECT ?"},
+		{"SELECT 1 # trailing", "SELECT ?"},
+		{"SELECT /* hint */ a FROM t", "SELECT a FROM t"},
+		{"/* app:checkout */ SELECT a FROM t;", "SELECT a FROM t"},
+
+		// keyword case and whitespace
+		{"select  a\n\tfrom t  where b=1", "SELECT a FROM t WHERE b = ?"},
+		{"SeLeCt count(*) FROM t", "SELECT COUNT(*) FROM t"},
+
+		// identifiers
+		{"SELECT * FROM t1", "SELECT * FROM t1"},
+		{"SELECT * FROM t2", "SELECT * FROM t2"},
+		{"SELECT `Order`.`userId` FROM `Order`", "SELECT `Order`.userId FROM `Order`"},
+		{"SELECT * FROM shop.order_items WHERE sku = 'A1'", "SELECT * FROM shop.order_items WHERE sku = ?"},
+
+		// non-reserved keywords are identifiers unless their position makes them keywords
+		{"select value, mode from skip where offset = 1", "SELECT value, mode FROM skip WHERE offset = ?"},
+		{"select * from `share` s where s.locked = 0 and s.keys_count > 1", "SELECT * FROM share s WHERE s.locked = ? AND s.keys_count > ?"},
+		{"select id from jobs where quick = 1 for update skip locked", "SELECT id FROM jobs WHERE quick = ? FOR UPDATE SKIP LOCKED"},
+		{"select id from jobs for share nowait", "SELECT id FROM jobs FOR SHARE NOWAIT"},
+		{"select id from t lock in share mode", "SELECT id FROM t LOCK IN SHARE MODE"},
+		{"select id from t limit 10 offset 20", "SELECT id FROM t LIMIT ? OFFSET ?"},
+		{"insert into t (a) value (1) on duplicate key update a = 2", "INSERT INTO t (a) VALUE (?+) ON DUPLICATE KEY UPDATE a = ?"},
+		{"select case when a then 1 end as `end`, work from t", "SELECT CASE WHEN a THEN ? END AS end, work FROM t"},
+		{"start transaction", "START TRANSACTION"},
+		{"commit work", "COMMIT WORK"},
+		{"delete quick from t where name like 'a!%' escape '!'", "DELETE QUICK FROM t WHERE name LIKE ? ESCAPE ?"},
+		{"select truncate(price, 2) from t", "SELECT TRUNCATE(price, ?) FROM t"},
+	}
+	for _, tt := range tests {
+		if got := Fingerprint(tt.in); got != tt.want {
+			t.Errorf("Fingerprint(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
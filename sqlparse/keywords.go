@@ -0,0 +1,144 @@
+package sqlparse
+
+import "strings"
+
+// reserved lists MySQL's reserved words: normalized to upper case by Fingerprint
+// and never taken for identifiers
+var reserved = toSet(`
+	ACCESSIBLE ADD ALL ALTER ANALYZE AND AS ASC BETWEEN BINARY BOTH BY CALL CASCADE CASE CHANGE CHECK
+	COLLATE COLUMN CONSTRAINT CONVERT CREATE CROSS CURRENT_DATE CURRENT_TIME CURRENT_TIMESTAMP
+	CURRENT_USER DATABASE DEFAULT DELAYED DELETE DESC DESCRIBE DISTINCT DISTINCTROW DIV DROP DUAL
+	ELSE ELSEIF EXISTS EXPLAIN FALSE FETCH FOR FORCE FOREIGN FROM FULLTEXT GROUP
+	HAVING HIGH_PRIORITY IF IGNORE IN INDEX INNER INSERT INTERVAL INTO IS JOIN KEY KEYS KILL
+	LATERAL LEADING LEFT LIKE LIMIT LOAD LOCK LOW_PRIORITY MATCH MOD NATURAL NOT NULL ON
+	OPTIMIZE OR ORDER OUTER OVER PARTITION PRIMARY PROCEDURE REGEXP RELEASE RENAME REPLACE
+	REFERENCES RIGHT RLIKE SCHEMA SELECT SEPARATOR SET SHOW SQL_BIG_RESULT SQL_CALC_FOUND_ROWS
+	SQL_SMALL_RESULT STRAIGHT_JOIN TABLE THEN TO TRAILING TRUE UNION UNIQUE UNLOCK UPDATE USE USING
+	VALUES WHEN WHERE WINDOW WITH XOR
+`)
+
+// keywords lists the non-reserved keywords Fingerprint upper-cases. They are
+// common column and table names, so IsKeyword tells them apart by position.
+var keywords = toSet(`
+	BEGIN COMMIT DO DUPLICATE END ESCAPE HANDLER LOCKED MODE NOWAIT OFFSET QUICK RETURNING ROLLBACK
+	ROLLUP SAVEPOINT SHARE SKIP SQL_BUFFER_RESULT SQL_NO_CACHE START TRANSACTION TRUNCATE VALUE WORK
+`)
+
+// statementKeywords are the non-reserved keywords that start a statement
+var statementKeywords = toSet(`BEGIN COMMIT DO HANDLER ROLLBACK SAVEPOINT START TRUNCATE`)
+
+// selectModifiers may sit between SELECT and the select list
+var selectModifiers = toSet(`ALL DISTINCT DISTINCTROW HIGH_PRIORITY STRAIGHT_JOIN SQL_SMALL_RESULT
+	SQL_BIG_RESULT SQL_BUFFER_RESULT SQL_NO_CACHE SQL_CALC_FOUND_ROWS`)
+
+// functions lists built-in function names; they are upper-cased like keywords
+// but, unlike keywords, are written without a space before their '('
+var functions = toSet(`
+	ABS AVG BIT_AND BIT_OR CAST CEIL CEILING CHAR_LENGTH COALESCE CONCAT CONCAT_WS COUNT CURDATE
+	CURTIME DATE DATE_ADD DATE_FORMAT DATE_SUB DATEDIFF DAY DAYOFWEEK EXTRACT FIELD FIND_IN_SET FLOOR
+	FROM_UNIXTIME GREATEST GROUP_CONCAT HOUR IFNULL INSTR ISNULL JSON_CONTAINS JSON_EXTRACT
+	JSON_UNQUOTE LAST_INSERT_ID LCASE LEAST LENGTH LOCATE LOWER LPAD LTRIM MAX MD5 MIN MINUTE MONTH NOW
+	NULLIF RAND ROUND ROW_NUMBER RPAD RTRIM SHA1 SHA2 STR_TO_DATE SUBSTR SUBSTRING SUBSTRING_INDEX
+	SOUNDEX SUM SYSDATE TIMESTAMPDIFF TRIM TRUNCATE UCASE UNIX_TIMESTAMP UPPER UTC_TIMESTAMP UUID WEEK YEAR
+`)
+
+func toSet(words string) map[string]struct{} {
+	set := map[string]struct{}{}
+	for _, w := range strings.Fields(words) {
+		set[w] = struct{}{}
+	}
+	return set
+}
+
+// IsReserved reports whether word is a reserved SQL keyword
+func IsReserved(word string) bool {
+	_, ok := reserved[strings.ToUpper(word)]
+	return ok
+}
+
+// IsFunction reports whether word is a known built-in function name
+func IsFunction(word string) bool {
+	_, ok := functions[strings.ToUpper(word)]
+	return ok
+}
+
+// IsKeyword reports whether tokens[i] is a keyword: a reserved word, or a
+// non-reserved keyword in a position where it cannot be an identifier, such as
+// SKIP LOCKED after FOR UPDATE, LOCK IN SHARE MODE, LIMIT ? OFFSET ? or
+// INSERT ... VALUE (...)
+func IsKeyword(tokens []Token, i int) bool {
+	t := tokens[i]
+	if t.Kind != TokenWord {
+		return false
+	}
+	word := strings.ToUpper(t.Text)
+	if _, ok := reserved[word]; ok {
+		return true
+	}
+	if _, ok := keywords[word]; !ok {
+		return false
+	}
+	prev := func(k int) Token {
+		if i-k < 0 {
+			return Token{Kind: TokenOperator, Text: ";"}
+		}
+		return tokens[i-k]
+	}
+	next := func(k int) Token {
+		if i+k >= len(tokens) {
+			return Token{Kind: TokenOperator, Text: ";"}
+		}
+		return tokens[i+k]
+	}
+	if _, ok := statementKeywords[word]; ok && prev(1).IsOp(";") {
+		return true
+	}
+	switch word {
+	case "WORK":
+		return prev(1).Is("COMMIT") || prev(1).Is("ROLLBACK") || prev(1).Is("BEGIN")
+	case "TRANSACTION":
+		return prev(1).Is("START")
+	case "SAVEPOINT":
+		return prev(1).Is("TO") || prev(1).Is("RELEASE")
+	case "SKIP":
+		return next(1).Is("LOCKED")
+	case "LOCKED":
+		return prev(1).Is("SKIP")
+	case "NOWAIT":
+		return prev(1).Is("UPDATE") || prev(1).Is("SHARE") || prev(2).Is("OF")
+	case "SHARE":
+		return prev(1).Is("FOR") || prev(1).Is("IN") && prev(2).Is("LOCK")
+	case "MODE":
+		return prev(1).Is("SHARE") && prev(2).Is("IN")
+	case "DUPLICATE":
+		return prev(1).Is("ON") && next(1).Is("KEY")
+	case "OFFSET":
+		return prev(1).IsLiteral() && (next(1).IsLiteral() || next(1).Kind == TokenVariable)
+	case "ESCAPE":
+		return prev(2).Is("LIKE") && next(1).IsLiteral()
+	case "VALUE":
+		return next(1).IsOp("(")
+	case "QUICK":
+		return prev(1).Is("DELETE") || prev(1).Is("LOW_PRIORITY")
+	case "ROLLUP":
+		return prev(1).Is("WITH")
+	case "SQL_BUFFER_RESULT", "SQL_NO_CACHE":
+		_, ok := selectModifiers[strings.ToUpper(prev(1).Text)]
+		return prev(1).Is("SELECT") || prev(1).Kind == TokenWord && ok
+	case "RETURNING":
+		return prev(1).IsLiteral() || prev(1).IsOp(")") || next(1).IsOp("*")
+	case "END":
+		// closes a CASE opened before it
+		open := 0
+		for _, tok := range tokens[:i] {
+			switch {
+			case tok.Is("CASE"):
+				open++
+			case tok.Is("END") && open > 0:
+				open--
+			}
+		}
+		return open > 0
+	}
+	return false
+}
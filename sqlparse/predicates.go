@@ -0,0 +1,291 @@
+package sqlparse
+
+import "strings"
+
+// ColumnUse says how a statement narrows or sorts on a column
+type ColumnUse string
+
+const (
+	UseEquality ColumnUse = "equality" // =, <=>, IN, IS NULL and join conditions
+	UseRange    ColumnUse = "range"    // <, >, <=, >=, BETWEEN, LIKE, IS NOT NULL
+	UseOrder    ColumnUse = "order"    // top-level ORDER BY
+)
+
+// ColumnRef is a column a statement filters, joins or sorts on
+type ColumnRef struct {
+	Schema string // of the table, empty if not qualified
+	Table  string // empty if the column is not qualified and the statement reads several tables
+	Name   string
+	Use    ColumnUse
+	Desc   bool // sorted descending
+}
+
+// conditionEnd are the keywords that end a WHERE or ON condition at its depth
+var conditionEnd = toSet(`GROUP HAVING ORDER LIMIT WINDOW UNION FOR LOCK INTO RETURNING EXCEPT INTERSECT
+	WHERE ON USING SET JOIN STRAIGHT_JOIN INNER LEFT RIGHT CROSS NATURAL OUTER`)
+
+// orderEnd are the keywords that may follow the last item of an ORDER BY
+var orderEnd = toSet(`LIMIT FOR LOCK INTO`)
+
+// Predicates returns the columns of the WHERE and JOIN ... ON conditions an index
+// can serve, and the columns of the top-level ORDER BY, in order of appearance.
+// Conditions joined by OR, negated or on expressions are left out, as is an ORDER
+// BY that is not a plain list of columns. Qualified columns are resolved through
+// the aliases of Tables; unqualified ones get the table of statements reading a
+// single table.
+func Predicates(sql string) []ColumnRef {
+	t := StripComments(Tokenize(sql))
+	p := predicateParser{tokens: t, depth: make([]int, len(t)), tables: Tables(sql)}
+	depth, union := 0, false
+	for i, tok := range t {
+		if tok.IsOp(")") {
+			depth--
+		}
+		p.depth[i] = depth
+		if tok.IsOp("(") {
+			depth++
+		}
+		union = union || depth == 0 && tok.Is("UNION")
+	}
+	for i, tok := range t {
+		switch {
+		case tok.Is("WHERE") || tok.Is("ON") && !(i+1 < len(t) && t[i+1].Is("DUPLICATE")):
+			p.conditions(i+1, p.conditionEnd(i+1, p.depth[i]))
+		case tok.Is("ORDER") && p.depth[i] == 0 && !union && i+1 < len(t) && t[i+1].Is("BY"):
+			p.orderBy(i + 2)
+		}
+	}
+	return dedupeColumns(p.refs)
+}
+
+type predicateParser struct {
+	tokens []Token
+	depth  []int // parenthesis depth of every token; ( and ) have the outer depth
+	tables []TableRef
+	refs   []ColumnRef
+}
+
+// conditionEnd returns the index after the condition starting at tokens[start]
+func (p *predicateParser) conditionEnd(start, depth int) int {
+	t := p.tokens
+	end := start
+	for end < len(t) && p.depth[end] >= depth {
+		if p.depth[end] == depth && (t[end].IsOp(";") || t[end].IsOp(",") || isConditionEnd(t[end].Text) && IsKeyword(t, end)) {
+			break
+		}
+		end++
+	}
+	return end
+}
+
+// conditions reads the AND-ed conditions of tokens[start:end], descending into
+// parenthesized groups. A level with an OR yields nothing: an index serves none of
+// the alternatives alone.
+func (p *predicateParser) conditions(start, end int) {
+	if start >= end {
+		return
+	}
+	t := p.tokens
+	base := p.depth[start]
+	var conjuncts [][2]int
+	from, between := start, false
+	for i := start; i < end; i++ {
+		if p.depth[i] != base {
+			continue
+		}
+		switch {
+		case t[i].Is("OR") || t[i].Is("XOR") || t[i].IsOp("||"):
+			return
+		case t[i].Is("BETWEEN"):
+			between = true
+		case t[i].Is("AND") || t[i].IsOp("&&"):
+			if between {
+				between = false // BETWEEN a AND b
+				continue
+			}
+			conjuncts = append(conjuncts, [2]int{from, i})
+			from = i + 1
+		}
+	}
+	conjuncts = append(conjuncts, [2]int{from, end})
+	for _, c := range conjuncts {
+		if c[1]-c[0] > 2 && t[c[0]].IsOp("(") && !t[c[0]+1].Is("SELECT") && p.closingParen(c[0]) == c[1]-1 {
+			p.conditions(c[0]+1, c[1]-1)
+			continue
+		}
+		p.condition(c[0], c[1])
+	}
+}
+
+// condition reads one comparison: column op value, value op column, column op
+// column (a join), or column IN / BETWEEN / LIKE / IS [NOT] NULL
+func (p *predicateParser) condition(start, end int) {
+	t := p.tokens
+	base := p.depth[start]
+	for op := start; op < end; op++ {
+		if p.depth[op] != base || t[op].Kind != TokenOperator {
+			continue
+		}
+		var use ColumnUse
+		switch t[op].Text {
+		case "=", "<=>":
+			use = UseEquality
+		case "<", ">", "<=", ">=":
+			use = UseRange
+		default:
+			continue
+		}
+		left, right := p.columnAt(start, op), p.columnAt(op+1, end)
+		switch {
+		case left != nil && right != nil:
+			if use == UseEquality {
+				p.add(*left, use)
+				p.add(*right, use)
+			}
+		case left != nil:
+			p.add(*left, use)
+		case right != nil:
+			p.add(*right, use)
+		}
+		return
+	}
+
+	col, next := p.column(start, end)
+	if col == nil || next >= end {
+		return
+	}
+	switch {
+	case t[next].Is("IN"):
+		p.add(*col, UseEquality)
+	case t[next].Is("BETWEEN") || t[next].Is("LIKE"):
+		p.add(*col, UseRange)
+	case t[next].Is("IS") && next+1 < end && t[next+1].Is("NULL"):
+		p.add(*col, UseEquality)
+	case t[next].Is("IS") && next+2 < end && t[next+1].Is("NOT") && t[next+2].Is("NULL"):
+		p.add(*col, UseRange)
+	}
+}
+
+// orderBy reads the ORDER BY list starting at tokens[i]; every item must be a column
+func (p *predicateParser) orderBy(i int) {
+	t := p.tokens
+	var refs []ColumnRef
+	for i < len(t) {
+		col, next := p.column(i, len(t))
+		if col == nil {
+			return
+		}
+		col.Use = UseOrder
+		if next < len(t) && (t[next].Is("ASC") || t[next].Is("DESC")) {
+			col.Desc = t[next].Is("DESC")
+			next++
+		}
+		refs = append(refs, *col)
+		switch {
+		case next >= len(t) || t[next].IsOp(";") || t[next].Kind == TokenWord && isOrderEnd(t[next].Text):
+			p.refs = append(p.refs, refs...)
+			return
+		case !t[next].IsOp(","):
+			return // an expression: the sort needs more than an index
+		}
+		i = next + 1
+	}
+}
+
+// column reads a column name, optionally qualified as table.column or
+// schema.table.column, at tokens[i] and returns it with the index after it
+func (p *predicateParser) column(i, end int) (*ColumnRef, int) {
+	t := p.tokens
+	var parts []string
+	for i < end && t[i].IsIdent() && !IsKeyword(t, i) {
+		parts = append(parts, t[i].Text)
+		i++
+		if len(parts) == 3 || i+1 >= end || !t[i].IsOp(".") {
+			break
+		}
+		i++
+	}
+	if len(parts) == 0 || i < end && t[i].IsOp("(") {
+		return nil, i // a function call
+	}
+	col := ColumnRef{Name: parts[len(parts)-1]}
+	switch len(parts) {
+	case 3:
+		col.Schema, col.Table = parts[0], parts[1]
+	case 2:
+		col.Table = parts[0]
+		for _, ref := range p.tables {
+			if strings.EqualFold(ref.Alias, parts[0]) || ref.Alias == "" && strings.EqualFold(ref.Name, parts[0]) {
+				col.Schema, col.Table = ref.Schema, ref.Name
+				break
+			}
+		}
+	default:
+		if ref, ok := p.onlyTable(); ok {
+			col.Schema, col.Table = ref.Schema, ref.Name
+		}
+	}
+	return &col, i
+}
+
+// columnAt returns the column that makes up all of tokens[start:end], or nil
+func (p *predicateParser) columnAt(start, end int) *ColumnRef {
+	if col, next := p.column(start, end); col != nil && next == end {
+		return col
+	}
+	return nil
+}
+
+// onlyTable returns the table of a statement that reads a single table
+func (p *predicateParser) onlyTable() (TableRef, bool) {
+	if len(p.tables) == 0 {
+		return TableRef{}, false
+	}
+	first := p.tables[0]
+	for _, ref := range p.tables[1:] {
+		if !strings.EqualFold(ref.Schema, first.Schema) || !strings.EqualFold(ref.Name, first.Name) {
+			return TableRef{}, false
+		}
+	}
+	return first, true
+}
+
+func (p *predicateParser) add(col ColumnRef, use ColumnUse) {
+	col.Use = use
+	p.refs = append(p.refs, col)
+}
+
+// closingParen returns the index of the ')' matching the '(' at open, or -1
+func (p *predicateParser) closingParen(open int) int {
+	for j := open + 1; j < len(p.tokens); j++ {
+		if p.tokens[j].IsOp(")") && p.depth[j] == p.depth[open] {
+			return j
+		}
+	}
+	return -1
+}
+
+func isConditionEnd(word string) bool {
+	_, ok := conditionEnd[strings.ToUpper(word)]
+	return ok
+}
+
+func isOrderEnd(word string) bool {
+	_, ok := orderEnd[strings.ToUpper(word)]
+	return ok
+}
+
+// dedupeColumns keeps the first reference of every column and use
+func dedupeColumns(refs []ColumnRef) []ColumnRef {
+	seen := map[ColumnRef]bool{}
+	var out []ColumnRef
+	for _, r := range refs {
+		key := ColumnRef{Schema: strings.ToLower(r.Schema), Table: strings.ToLower(r.Table), Name: strings.ToLower(r.Name), Use: r.Use}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, r)
+	}
+	return out
+}
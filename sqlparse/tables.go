@@ -0,0 +1,273 @@
+package sqlparse
+
+import "strings"
+
+// TableRole says how a statement uses a table
+type TableRole string
+
+const (
+	RoleRead  TableRole = "read"  // first table of a FROM clause or subquery
+	RoleJoin  TableRole = "join"  // joined with JOIN or a comma
+	RoleWrite TableRole = "write" // modified: INSERT, REPLACE, UPDATE, DELETE and DDL targets
+)
+
+// TableRef is a table referenced by a statement
+type TableRef struct {
+	Schema string // empty if not qualified
+	Name   string
+	Alias  string
+	Role   TableRole
+}
+
+// writeStatements start with a verb whose TABLE target is modified
+var writeStatements = toSet(`ALTER CREATE DROP TRUNCATE RENAME OPTIMIZE ANALYZE`)
+
+// fromListEnd are the keywords that end a table list
+var fromListEnd = toSet(`WHERE GROUP HAVING ORDER LIMIT UNION SET ON USING WINDOW FOR LOCK INTO VALUES VALUE SELECT PARTITION EXCEPT INTERSECT RETURNING`)
+
+// Tables returns the tables a statement references with their role, in order of
+// appearance: targets of INSERT/REPLACE/UPDATE/DELETE and DDL, FROM and JOIN
+// tables, including those of subqueries. Common table expressions are left out.
+// A table used in several roles is listed once per role.
+func Tables(sql string) []TableRef {
+	tokens := StripComments(Tokenize(sql))
+	p := tableParser{tokens: tokens, ctes: cteNames(tokens)}
+	p.parse()
+	return p.refs
+}
+
+type tableParser struct {
+	tokens []Token
+	ctes   map[string]struct{}
+	refs   []TableRef
+}
+
+func (p *tableParser) parse() {
+	t := p.tokens
+	start := 0
+	if start < len(t) && t[start].Is("WITH") {
+		// the main statement follows the last CTE's parenthesis at depth 0
+		for i, depth := 0, 0; i < len(t); i++ {
+			switch {
+			case t[i].IsOp("("):
+				depth++
+			case t[i].IsOp(")"):
+				depth--
+			case depth == 0 && i > 0 && t[i-1].IsOp(")") && !t[i].IsOp(",") && !t[i].Is("AS"):
+				start = i
+				i = len(t)
+			}
+		}
+	}
+	verb := ""
+	if start < len(t) && t[start].Kind == TokenWord {
+		verb = strings.ToUpper(t[start].Text)
+	}
+
+	var deleteTargets []string // multi-table DELETE: names or aliases before FROM
+	role := RoleRead           // role of the next table of a list
+	inList := -1               // paren depth of the table list being read, -1 outside
+	depth := 0
+	var funcParens []bool // per open paren: belongs to a function call
+	for i := 0; i < len(t); i++ {
+		tok := t[i]
+		switch {
+		case tok.IsOp("("):
+			funcParens = append(funcParens, i > 0 && t[i-1].Kind == TokenWord && (IsFunction(t[i-1].Text) || !IsReserved(t[i-1].Text)))
+			depth++
+			continue
+		case tok.IsOp(")"):
+			if len(funcParens) > 0 {
+				funcParens = funcParens[:len(funcParens)-1]
+			}
+			depth--
+			if inList > depth {
+				inList = -1
+			}
+			continue
+		}
+		inFunc := len(funcParens) > 0 && funcParens[len(funcParens)-1]
+
+		switch {
+		case i == start && (verb == "INSERT" || verb == "REPLACE"):
+			j := i + 1
+			for j < len(t) && (t[j].Is("LOW_PRIORITY") || t[j].Is("DELAYED") || t[j].Is("HIGH_PRIORITY") || t[j].Is("IGNORE") || t[j].Is("INTO")) {
+				j++
+			}
+			i = p.table(j, RoleWrite) - 1
+		case i == start && verb == "UPDATE":
+			j := i + 1
+			for j < len(t) && (t[j].Is("LOW_PRIORITY") || t[j].Is("IGNORE")) {
+				j++
+			}
+			i = p.table(j, RoleWrite) - 1
+			role, inList = RoleJoin, depth
+		case i == start && verb == "DELETE":
+			j := i + 1
+			for j < len(t) && (t[j].Is("LOW_PRIORITY") || t[j].Is("QUICK") || t[j].Is("IGNORE")) {
+				j++
+			}
+			from := j
+			for from < len(t) && !t[from].Is("FROM") {
+				from++
+			}
+			deleteTargets = targetNames(t[j:from])
+			switch {
+			case len(deleteTargets) > 0:
+				// DELETE t1, t2 FROM t1 JOIN t2 ...: the FROM clause follows
+				i = from - 1
+			case hasKeyword(t[from:], "USING"):
+				// DELETE FROM t1, t2 USING t1 JOIN t2 ...
+				using := from
+				for using < len(t) && !t[using].Is("USING") {
+					using++
+				}
+				deleteTargets = targetNames(t[from+1 : using])
+				i = p.table(using+1, RoleRead) - 1
+				role, inList = RoleJoin, depth
+			default:
+				i = p.table(from+1, RoleWrite) - 1
+				role, inList = RoleJoin, depth
+			}
+		case i == start && verb == "TRUNCATE" && i+1 < len(t) && !t[i+1].Is("TABLE"):
+			i = p.table(i+1, RoleWrite) - 1
+		case i == start && verb == "TABLE":
+			i = p.table(i+1, RoleRead) - 1
+		case tok.Is("TABLE") && i > start && isWriteVerb(verb):
+			j := i + 1
+			for j < len(t) && (t[j].Is("IF") || t[j].Is("NOT") || t[j].Is("EXISTS")) {
+				j++
+			}
+			i = p.table(j, RoleWrite) - 1
+		case tok.Is("INTO") && i > start && !inFunc:
+			// INSERT ... SELECT has its target first; this is LOAD DATA ... INTO TABLE
+			j := i + 1
+			if j < len(t) && t[j].Is("TABLE") {
+				i = p.table(j+1, RoleWrite) - 1
+			}
+		case tok.Is("FROM") && !inFunc:
+			i = p.table(i+1, RoleRead) - 1
+			role, inList = RoleJoin, depth
+		case tok.Is("JOIN") || tok.Is("STRAIGHT_JOIN"):
+			i = p.table(i+1, RoleJoin) - 1
+			role, inList = RoleJoin, depth
+		case tok.IsOp(",") && inList == depth:
+			i = p.table(i+1, role) - 1
+		case inList == depth && tok.Kind == TokenWord && isListEnd(tok.Text):
+			inList = -1
+		}
+	}
+
+	if len(deleteTargets) > 0 {
+		for i := range p.refs {
+			for _, name := range deleteTargets {
+				if strings.EqualFold(p.refs[i].Alias, name) || p.refs[i].Alias == "" && strings.EqualFold(p.refs[i].Name, name) {
+					p.refs[i].Role = RoleWrite
+				}
+			}
+		}
+	}
+	p.refs = dedupeRefs(p.refs)
+}
+
+// table reads a table name with optional schema and alias at tokens[i] and
+// returns the index after it. Subqueries, variables and CTE names are skipped.
+func (p *tableParser) table(i int, role TableRole) int {
+	t := p.tokens
+	if i >= len(t) || !t[i].IsIdent() || t[i].Is("OUTFILE") || t[i].Is("DUMPFILE") {
+		return i
+	}
+	ref := TableRef{Name: t[i].Text, Role: role}
+	i++
+	if i+1 < len(t) && t[i].IsOp(".") && t[i+1].IsIdent() {
+		ref.Schema, ref.Name = ref.Name, t[i+1].Text
+		i += 2
+	}
+	if i < len(t) && t[i].Is("AS") {
+		i++
+	}
+	if i < len(t) && t[i].IsIdent() && !IsKeyword(t, i) {
+		ref.Alias = t[i].Text
+		i++
+	}
+	if _, ok := p.ctes[strings.ToLower(ref.Name)]; ok && ref.Schema == "" {
+		return i
+	}
+	p.refs = append(p.refs, ref)
+	return i
+}
+
+// cteNames collects the names defined by WITH: an identifier followed by AS (,
+// or by a column list and AS (, right after WITH, RECURSIVE or a comma
+func cteNames(t []Token) map[string]struct{} {
+	names := map[string]struct{}{}
+	if len(t) == 0 || !t[0].Is("WITH") {
+		return names
+	}
+	for i := 1; i < len(t); i++ {
+		if !t[i].IsIdent() || !(t[i-1].Is("WITH") || t[i-1].Is("RECURSIVE") || t[i-1].IsOp(",")) {
+			continue
+		}
+		j := i + 1
+		if j < len(t) && t[j].IsOp("(") {
+			if end := matchingParen(t, j); end > 0 {
+				j = end + 1
+			}
+		}
+		if j+1 < len(t) && t[j].Is("AS") && t[j+1].IsOp("(") {
+			names[strings.ToLower(t[i].Text)] = struct{}{}
+		}
+	}
+	return names
+}
+
+// targetNames returns the tables or aliases listed as targets of a multi-table
+// DELETE, such as t1, db.t2 or t3.*
+func targetNames(tokens []Token) []string {
+	var names []string
+	for i := 0; i < len(tokens); i++ {
+		if !tokens[i].IsIdent() {
+			continue
+		}
+		if i+2 < len(tokens) && tokens[i+1].IsOp(".") && tokens[i+2].IsIdent() {
+			i += 2
+		}
+		names = append(names, tokens[i].Text)
+	}
+	return names
+}
+
+// isWriteVerb reports whether statements starting with verb modify their TABLE target
+func isWriteVerb(verb string) bool {
+	_, ok := writeStatements[verb]
+	return ok
+}
+
+func isListEnd(word string) bool {
+	_, ok := fromListEnd[strings.ToUpper(word)]
+	return ok
+}
+
+func hasKeyword(tokens []Token, keyword string) bool {
+	for _, t := range tokens {
+		if t.Is(keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// dedupeRefs keeps the first reference of every table and role
+func dedupeRefs(refs []TableRef) []TableRef {
+	seen := map[TableRef]bool{}
+	var out []TableRef
+	for _, r := range refs {
+		key := TableRef{Schema: strings.ToLower(r.Schema), Name: strings.ToLower(r.Name), Role: r.Role}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, r)
+	}
+	return out
+}
@@ -0,0 +1,51 @@
+package sqlparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTables(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []TableRef
+	}{
+		{"SELECT * FROM orders o JOIN customers c ON c.id = o.customer_id",
+			[]TableRef{{Name: "orders", Alias: "o", Role: RoleRead}, {Name: "customers", Alias: "c", Role: RoleJoin}}},
+		{"SELECT * FROM skip", []TableRef{{Name: "skip", Role: RoleRead}}},
+		{"SELECT * FROM shop.share AS mode WHERE value = ?", []TableRef{{Schema: "shop", Name: "share", Alias: "mode", Role: RoleRead}}},
+		{"SELECT id FROM jobs FOR UPDATE SKIP LOCKED", []TableRef{{Name: "jobs", Role: RoleRead}}},
+		{"INSERT INTO value VALUE (?)", []TableRef{{Name: "value", Role: RoleWrite}}},
+	}
+	for _, tt := range tests {
+		if got := Tables(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tables(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPredicates(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []ColumnRef
+	}{
+		{"SELECT * FROM t WHERE value = ? AND offset > ? ORDER BY mode DESC LIMIT ? OFFSET ?", []ColumnRef{
+			{Table: "t", Name: "value", Use: UseEquality},
+			{Table: "t", Name: "offset", Use: UseRange},
+			{Table: "t", Name: "mode", Use: UseOrder, Desc: true},
+		}},
+		{"SELECT * FROM jobs WHERE status = ? AND skip IS NULL FOR UPDATE SKIP LOCKED", []ColumnRef{
+			{Table: "jobs", Name: "status", Use: UseEquality},
+			{Table: "jobs", Name: "skip", Use: UseEquality},
+		}},
+		{"SELECT * FROM a JOIN b ON b.a_id = a.id WHERE a.x = ? OR b.y = ?", []ColumnRef{
+			{Table: "b", Name: "a_id", Use: UseEquality},
+			{Table: "a", Name: "id", Use: UseEquality},
+		}},
+	}
+	for _, tt := range tests {
+		if got := Predicates(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Predicates(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
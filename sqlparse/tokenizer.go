@@ -0,0 +1,242 @@
+package sqlparse
+
+import (
+	"strings"
+)
+
+// TokenKind classifies a lexical SQL token
+type TokenKind int
+
+const (
+	TokenWord        TokenKind = iota // keyword or unquoted identifier
+	TokenQuotedIdent                  // `backticked` identifier, Text holds the bare name
+	TokenString                       // 'string', "string", X'..', N'..'
+	TokenNumber                       // 42, -1.5e3, 0xFF, b'01'
+	TokenPlaceholder                  // ? from prepared statements or fingerprints
+	TokenVariable                     // @user_var, @@system_var
+	TokenOperator                     // punctuation and operators: ( ) , . ; = <> <= ...
+	TokenComment                      // /* ... */, -- ..., # ...
+)
+
+// Token is one lexical element of a statement
+type Token struct {
+	Kind TokenKind
+	Text string
+}
+
+// Is reports whether the token is the given keyword, case-insensitively
+func (t Token) Is(keyword string) bool {
+	return t.Kind == TokenWord && strings.EqualFold(t.Text, keyword)
+}
+
+// IsOp reports whether the token is the given operator or punctuation
+func (t Token) IsOp(op string) bool {
+	return t.Kind == TokenOperator && t.Text == op
+}
+
+// IsLiteral reports whether the token is a value literal or placeholder
+func (t Token) IsLiteral() bool {
+	return t.Kind == TokenString || t.Kind == TokenNumber || t.Kind == TokenPlaceholder
+}
+
+// IsIdent reports whether the token can name a schema object
+func (t Token) IsIdent() bool {
+	return t.Kind == TokenQuotedIdent || t.Kind == TokenWord && !IsReserved(t.Text)
+}
+
+// multiCharOps are matched greedily before single-character operators
+var multiCharOps = []string{"<=>", "<<", ">>", "<=", ">=", "<>", "!=", "||", "&&", ":=", "->>", "->"}
+
+// Tokenize splits a statement into tokens, dropping whitespace. Comments are kept
+// as TokenComment so callers can decide whether to use them.
+func Tokenize(sql string) []Token {
+	var tokens []Token
+	i := 0
+	n := len(sql)
+	for i < n {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+			i++
+
+		case c == '/' && i+1 < n && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end == -1 {
+				end = n
+			} else {
+				end = i + 2 + end + 2
+			}
+			tokens = append(tokens, Token{TokenComment, sql[i:end]})
+			i = end
+
+		case c == '#' || c == '-' && i+2 < n && sql[i+1] == '-' && (sql[i+2] == ' ' || sql[i+2] == '\t') ||
+			c == '-' && i+2 == n && sql[i+1] == '-':
+			end := strings.IndexByte(sql[i:], '\n')
+			if end == -1 {
+				end = n
+			} else {
+				end += i
+			}
+			tokens = append(tokens, Token{TokenComment, sql[i:end]})
+			i = end
+
+		case c == '\'' || c == '"':
+			end := scanQuoted(sql, i, c)
+			tokens = append(tokens, Token{TokenString, sql[i:end]})
+			i = end
+
+		case c == '`':
+			end := scanQuoted(sql, i, '`')
+			name := sql[i+1 : max(i+1, end-1)]
+			tokens = append(tokens, Token{TokenQuotedIdent, strings.ReplaceAll(name, "``", "`")})
+			i = end
+
+		case (c == 'x' || c == 'X' || c == 'b' || c == 'B' || c == 'n' || c == 'N') && i+1 < n && sql[i+1] == '\'':
+			end := scanQuoted(sql, i+1, '\'')
+			kind := TokenString
+			if c == 'b' || c == 'B' {
+				kind = TokenNumber
+			}
+			tokens = append(tokens, Token{kind, sql[i:end]})
+			i = end
+
+		case c == '0' && i+1 < n && (sql[i+1] == 'x' || sql[i+1] == 'X' || sql[i+1] == 'b' || sql[i+1] == 'B') && i+2 < n && isHexDigit(sql[i+2]):
+			j := i + 2
+			for j < n && isHexDigit(sql[j]) {
+				j++
+			}
+			tokens = append(tokens, Token{TokenNumber, sql[i:j]})
+			i = j
+
+		case isDigit(c) || c == '.' && i+1 < n && isDigit(sql[i+1]) && !afterIdent(tokens):
+			j := scanNumber(sql, i)
+			// identifiers may start with digits (e.g. 1st_table)
+			if j < n && isWordChar(sql[j]) {
+				k := j
+				for k < n && isWordChar(sql[k]) {
+					k++
+				}
+				tokens = append(tokens, Token{TokenWord, sql[i:k]})
+				i = k
+				continue
+			}
+			tokens = append(tokens, Token{TokenNumber, sql[i:j]})
+			i = j
+
+		case isWordStart(c):
+			j := i + 1
+			for j < n && isWordChar(sql[j]) {
+				j++
+			}
+			tokens = append(tokens, Token{TokenWord, sql[i:j]})
+			i = j
+
+		case c == '@':
+			j := i + 1
+			for j < n && sql[j] == '@' {
+				j++
+			}
+			if j < n && (sql[j] == '`' || sql[j] == '\'' || sql[j] == '"') {
+				j = scanQuoted(sql, j, sql[j])
+			} else {
+				for j < n && (isWordChar(sql[j]) || sql[j] == '.') {
+					j++
+				}
+			}
+			tokens = append(tokens, Token{TokenVariable, sql[i:j]})
+			i = j
+
+		case c == '?':
+			tokens = append(tokens, Token{TokenPlaceholder, "?"})
+			i++
+
+		default:
+			op := string(c)
+			for _, m := range multiCharOps {
+				if strings.HasPrefix(sql[i:], m) {
+					op = m
+					break
+				}
+			}
+			tokens = append(tokens, Token{TokenOperator, op})
+			i += len(op)
+		}
+	}
+	return tokens
+}
+
+// StripComments returns the tokens without comments
+func StripComments(tokens []Token) []Token {
+	out := tokens[:0:0]
+	for _, t := range tokens {
+		if t.Kind != TokenComment {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// scanQuoted returns the index just past the closing quote of the quoted run starting at i.
+// Doubled quotes and backslash escapes (not for identifiers) are skipped.
+func scanQuoted(sql string, i int, quote byte) int {
+	j := i + 1
+	for j < len(sql) {
+		switch sql[j] {
+		case '\\':
+			if quote != '`' {
+				j += 2
+				continue
+			}
+		case quote:
+			if j+1 < len(sql) && sql[j+1] == quote {
+				j += 2
+				continue
+			}
+			return j + 1
+		}
+		j++
+	}
+	return len(sql)
+}
+
+func scanNumber(sql string, i int) int {
+	j := i
+	for j < len(sql) && isDigit(sql[j]) {
+		j++
+	}
+	if j < len(sql) && sql[j] == '.' {
+		j++
+		for j < len(sql) && isDigit(sql[j]) {
+			j++
+		}
+	}
+	if j < len(sql) && (sql[j] == 'e' || sql[j] == 'E') {
+		k := j + 1
+		if k < len(sql) && (sql[k] == '+' || sql[k] == '-') {
+			k++
+		}
+		if k < len(sql) && isDigit(sql[k]) {
+			j = k
+			for j < len(sql) && isDigit(sql[j]) {
+				j++
+			}
+		}
+	}
+	return j
+}
+
+// afterIdent reports whether the last token is an identifier, so a following '.' is a qualifier
+func afterIdent(tokens []Token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	last := tokens[len(tokens)-1]
+	return last.Kind == TokenQuotedIdent || last.Kind == TokenWord && !IsReserved(last.Text)
+}
+
+func isDigit(c byte) bool    { return c >= '0' && c <= '9' }
+func isHexDigit(c byte) bool { return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F' }
+func isWordStart(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+func isWordChar(c byte) bool { return isWordStart(c) || isDigit(c) }
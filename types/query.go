@@ -1,25 +1,143 @@
 package types
 
+import (
+	"strings"
+	"time"
+)
+
 type SlowQuery struct {
-	ID           int
-	StartTime    string
-	UserHost     string
-	DB           string
-	QueryTime    string
-	RowsExamined int
-	SQLText      string
-	RowsSent     int
-	LockTime     string
-	QueryType    string
+	ID           int               `json:"id,omitempty"`
+	StartTime    string            `json:"start_time"`
+	UserHost     string            `json:"user_host"`
+	DB           string            `json:"db"`
+	QueryTime    string            `json:"query_time"`
+	RowsExamined int               `json:"rows_examined"`
+	SQLText      string            `json:"sql_text"`
+	RowsSent     int               `json:"rows_sent"`
+	LockTime     string            `json:"lock_time"`
+	QueryType    string            `json:"query_type,omitempty"`
+	Attributes   map[string]string `json:"attributes,omitempty"` // extended slow log attributes (Percona/MariaDB), nil if none
 }
 
 type GroupedQuery struct {
-	NormalizedSQL   string
-	QueryType       string
-	FromTable       string
-	Count           int
-	AvgQueryTime    float64
-	AvgRowsExamined float64
-	AvgRowsSent     float64
-	Examples        []SlowQuery
+	Digest            string // stable group ID: fingerprint checksum, or the server's statement digest
+	NormalizedSQL     string
+	QueryType         string
+	Tables            []TableRef // tables referenced by the statement, in order of appearance
+	Count             int
+	AvgQueryTime      float64
+	AvgRowsExamined   float64
+	AvgRowsSent       float64
+	TotalQueryTime    float64 // count × avg: total seconds spent in this query class
+	ImpactPercent     float64 // TotalQueryTime as a percentage of all groups' query time
+	QueryTimeStats    Stats   // seconds
+	LockTimeStats     Stats   // seconds
+	RowsExaminedStats Stats
+	RowsSentStats     Stats
+	Attributes        map[string]AttrStat // aggregated extended attributes, keyed by name
+	Databases         map[string]Share    // executions per default database (SlowQuery.DB), "" if none
+	Accounts          map[Account]Share   // executions per client account, empty if the source has no user_host
+	FirstSeen         string              // start time of the first/last execution, empty if the source has no times
+	LastSeen          string
+	Trend             Trend // executions over time, empty if the source has no per-execution times
+	Examples          []SlowQuery
+}
+
+// Roles of a referenced table
+const (
+	TableRead  = "read"  // first table of a FROM clause or subquery
+	TableJoin  = "join"  // joined with JOIN or a comma
+	TableWrite = "write" // modified by INSERT, REPLACE, UPDATE, DELETE or DDL
+)
+
+// TableRef is a table referenced by a group's statement and how it is used
+type TableRef struct {
+	Schema string // empty if not qualified in the statement
+	Name   string
+	Role   string // TableRead, TableJoin or TableWrite
+}
+
+// String returns the table name as written, schema-qualified if it was
+func (t TableRef) String() string {
+	if t.Schema == "" {
+		return t.Name
+	}
+	return t.Schema + "." + t.Name
+}
+
+// Trend buckets a group's executions over time. The groups of one load share
+// Start and Bucket, so their trends line up.
+type Trend struct {
+	Start  time.Time     // start of the first bucket
+	Bucket time.Duration // bucket width
+	Counts []int         // executions per bucket
+	Times  []float64     // query time per bucket, in seconds
+}
+
+// End returns the end of the last bucket
+func (t Trend) End() time.Time {
+	return t.Start.Add(time.Duration(len(t.Counts)) * t.Bucket)
+}
+
+// AttrStat aggregates one extended slow log attribute over a group
+type AttrStat struct {
+	Sum   float64 // total value, or number of "Yes" for flags
+	Count int     // executions that reported the attribute
+	Flag  bool    // Yes/No attribute such as Full_scan
+}
+
+// Avg returns the mean value per execution, or the fraction of "Yes" for flags
+func (a AttrStat) Avg() float64 {
+	if a.Count == 0 {
+		return 0
+	}
+	return a.Sum / float64(a.Count)
+}
+
+// Account is the user and client host of an execution
+type Account struct {
+	User string
+	Host string // host name, or IP when the host name is unknown
+}
+
+// String returns the account as user@host
+func (a Account) String() string {
+	return a.User + "@" + a.Host
+}
+
+// ParseUserHost splits a slow log user_host value such as "app[app] @ web1 [10.0.0.5]"
+// into the user name and the host name (or IP when the host name is empty)
+func ParseUserHost(userHost string) Account {
+	left, right, _ := strings.Cut(userHost, "@")
+	user := strings.TrimSpace(left)
+	if i := strings.Index(user, "["); i != -1 {
+		user = strings.TrimSpace(user[:i])
+	}
+	right = strings.TrimSpace(right)
+	host := right
+	if i := strings.Index(right, "["); i != -1 {
+		host = strings.TrimSpace(right[:i])
+		if host == "" {
+			host = strings.Trim(right[i:], "[] ")
+		}
+	}
+	return Account{User: user, Host: host}
+}
+
+// Share is the part of a group's executions and query time with one value of a field
+type Share struct {
+	Count int
+	Time  float64 // seconds
+}
+
+// Stats summarizes the distribution of one metric over a group's executions
+type Stats struct {
+	Min    float64
+	Max    float64
+	Avg    float64
+	Median float64
+	P95    float64
+	P99    float64
+	StdDev float64
+	Sum    float64
 }
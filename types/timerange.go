@@ -0,0 +1,100 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StartTimeLayout is the format of SlowQuery.StartTime, as returned by mysql.slow_log
+const StartTimeLayout = "2006-01-02 15:04:05.000000"
+
+// TimeRange restricts the slow log window; a zero bound is open
+type TimeRange struct {
+	Since time.Time // inclusive
+	Until time.Time // exclusive
+}
+
+// IsZero reports whether the range is unbounded on both ends
+func (r TimeRange) IsZero() bool {
+	return r.Since.IsZero() && r.Until.IsZero()
+}
+
+// Contains reports whether t falls inside the range
+func (r TimeRange) Contains(t time.Time) bool {
+	if !r.Since.IsZero() && t.Before(r.Since) {
+		return false
+	}
+	if !r.Until.IsZero() && !t.Before(r.Until) {
+		return false
+	}
+	return true
+}
+
+// ContainsStartTime reports whether a StartTime string falls inside the range.
+// Unparseable times are kept, so a range never hides records it cannot place.
+func (r TimeRange) ContainsStartTime(startTime string) bool {
+	if r.IsZero() {
+		return true
+	}
+	t, ok := ParseStartTime(startTime)
+	return !ok || r.Contains(t)
+}
+
+func (r TimeRange) String() string {
+	if r.IsZero() {
+		return "all time"
+	}
+	format := func(t time.Time, open string) string {
+		if t.IsZero() {
+			return open
+		}
+		return t.Format("2006-01-02 15:04:05")
+	}
+	return format(r.Since, "…") + " → " + format(r.Until, "now")
+}
+
+// ParseStartTime parses a SlowQuery.StartTime value in the local time zone
+func ParseStartTime(s string) (time.Time, bool) {
+	for _, layout := range []string{StartTimeLayout, "2006-01-02 15:04:05", time.RFC3339Nano} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ParseTimeBound parses a --since/--until value: an absolute local time
+// ("2024-01-02 15:04", "2024-01-02", RFC 3339) or a duration before now
+// ("90m", "2h", "7d"). An empty string is an open bound.
+func ParseTimeBound(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := parseRelative(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use e.g. 2h, 7d or 2024-01-02 15:04", s)
+}
+
+// parseRelative parses Go durations plus a "d" (day) suffix
+func parseRelative(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,219 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"slowlog-tui/db"
+	"slowlog-tui/types"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// executionOrders are the orders the executions list cycles through with "o"
+var executionOrders = []struct {
+	Name string
+	Less func(a, b types.SlowQuery) bool
+}{
+	{"Latest first", func(a, b types.SlowQuery) bool { return a.StartTime > b.StartTime }},
+	{"Oldest first", func(a, b types.SlowQuery) bool { return a.StartTime < b.StartTime }},
+	{"Slowest first", func(a, b types.SlowQuery) bool { return db.ParseTime(a.QueryTime) > db.ParseTime(b.QueryTime) }},
+	{"Fastest first", func(a, b types.SlowQuery) bool { return db.ParseTime(a.QueryTime) < db.ParseTime(b.QueryTime) }},
+	{"Most lock time", func(a, b types.SlowQuery) bool { return db.ParseTime(a.LockTime) > db.ParseTime(b.LockTime) }},
+	{"Most rows examined", func(a, b types.SlowQuery) bool { return a.RowsExamined > b.RowsExamined }},
+}
+
+// executionsView is the third navigation level: the individual executions of one
+// group, with the full SQL and metadata of the selected one
+type executionsView struct {
+	group      types.GroupedQuery
+	executions []types.SlowQuery // in the selected order
+	order      int               // index into executionOrders
+	table      table.Model
+	detail     viewport.Model
+	lastCursor int
+}
+
+// newExecutionsView lists the executions of g in a panel of the given size
+func newExecutionsView(g types.GroupedQuery, width, height int, mode HighlightMode) executionsView {
+	v := executionsView{group: g}
+	v.detail = viewport.New(width, 1)
+	v.resize(width, height, mode)
+	v.sortExecutions(mode)
+	return v
+}
+
+// resize splits the height between the list and the detail panel: both boxes and
+// the help line take 8 rows of borders, title and help text
+func (v *executionsView) resize(width, height int, mode HighlightMode) {
+	listHeight := max((height-8)/2, 3)
+	v.detail.Width = width
+	v.detail.Height = max(height-8-listHeight, 3)
+	cursor := v.table.Cursor()
+	v.table = newExecutionsTable(v.executions, width, listHeight)
+	v.table.SetCursor(cursor)
+	v.lastCursor = -1
+	v.updateDetail(mode)
+}
+
+// sortExecutions applies the selected order and rebuilds the list
+func (v *executionsView) sortExecutions(mode HighlightMode) {
+	v.executions = append([]types.SlowQuery(nil), v.group.Examples...)
+	less := executionOrders[v.order].Less
+	sort.SliceStable(v.executions, func(i, j int) bool { return less(v.executions[i], v.executions[j]) })
+	v.table = newExecutionsTable(v.executions, v.detail.Width, v.table.Height())
+	v.lastCursor = -1
+	v.updateDetail(mode)
+}
+
+// updateDetail shows the selected execution if the cursor moved
+func (v *executionsView) updateDetail(mode HighlightMode) {
+	i := v.table.Cursor()
+	if i == v.lastCursor || i < 0 || i >= len(v.executions) {
+		return
+	}
+	v.lastCursor = i
+	q := v.executions[i]
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Execution #%d | Started: %s | %s | DB: %s\n", q.ID, q.StartTime, q.UserHost, q.DB))
+	b.WriteString(fmt.Sprintf("Query time: %s | Lock time: %s | Rows examined: %d | Rows sent: %d\n",
+		formatDuration(db.ParseTime(q.QueryTime)), formatDuration(db.ParseTime(q.LockTime)), q.RowsExamined, q.RowsSent))
+	if len(q.Attributes) > 0 {
+		names := make([]string, 0, len(q.Attributes))
+		for name := range q.Attributes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts := make([]string, len(names))
+		for i, name := range names {
+			parts[i] = name + ": " + q.Attributes[name]
+		}
+		b.WriteString(lipgloss.NewStyle().Width(v.detail.Width).Render(strings.Join(parts, "  ")) + "\n")
+	}
+	b.WriteString("\n")
+	if mode == HighlightSimple {
+		b.WriteString(HighlightSQL(q.SQLText))
+	} else {
+		b.WriteString(q.SQLText)
+	}
+	v.detail.SetContent(b.String())
+	v.detail.GotoTop()
+}
+
+// newExecutionsTable builds the executions list
+func newExecutionsTable(executions []types.SlowQuery, width, height int) table.Model {
+	cols := []table.Column{
+		{Title: "#", Width: 6},
+		{Title: "Started", Width: 27},
+		{Title: "User@Host", Width: 30},
+		{Title: "DB", Width: 16},
+		{Title: "Query Time", Width: 11},
+		{Title: "Lock", Width: 9},
+		{Title: "Examined", Width: 10},
+		{Title: "Sent", Width: 8},
+	}
+	used := 0
+	for _, c := range cols {
+		used += c.Width
+	}
+	sqlWidth := max(width-used-2, 10)
+	cols = append(cols, table.Column{Title: "SQL", Width: sqlWidth})
+
+	rows := make([]table.Row, len(executions))
+	for i, q := range executions {
+		sqlText := strings.Join(strings.Fields(q.SQLText), " ")
+		if len(sqlText) > sqlWidth {
+			sqlText = sqlText[:sqlWidth-3] + "..."
+		}
+		rows[i] = table.Row{
+			fmt.Sprintf("%d", q.ID),
+			q.StartTime,
+			q.UserHost,
+			q.DB,
+			formatDuration(db.ParseTime(q.QueryTime)),
+			formatDuration(db.ParseTime(q.LockTime)),
+			fmt.Sprintf("%d", q.RowsExamined),
+			fmt.Sprintf("%d", q.RowsSent),
+			sqlText,
+		}
+	}
+	tbl := table.New(
+		table.WithColumns(cols),
+		table.WithRows(rows),
+		table.WithFocused(true),
+		table.WithHeight(height),
+	)
+	tbl.SetStyles(table.Styles{
+		Selected: selectedRowStyle,
+	})
+	return tbl
+}
+
+// openExecutions shows the executions of the group in the preview
+func (m *Model) openExecutions() {
+	i := m.table.Cursor()
+	if i < 0 || i >= len(m.filteredGroups) || len(m.filteredGroups[i].Examples) == 0 {
+		return
+	}
+	m.executions = newExecutionsView(m.filteredGroups[i], m.viewport.Width, m.height, m.highlightMode)
+	m.showExecutions = true
+}
+
+// updateExecutions handles keys while the executions list is open
+func (m Model) updateExecutions(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := &m.executions
+	var cmd tea.Cmd
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+	case "esc", "backspace":
+		m.showExecutions = false
+		return m, nil
+	case "o":
+		v.order = (v.order + 1) % len(executionOrders)
+		v.sortExecutions(m.highlightMode)
+		return m, nil
+	case "e", "E":
+		i := v.table.Cursor()
+		if i < 0 || i >= len(v.executions) {
+			return m, nil
+		}
+		q := v.executions[i]
+		m.showExecutions = false
+		req := explainRequest{digest: v.group.Digest, label: fmt.Sprintf("execution #%d", q.ID), query: q, analyze: msg.String() == "E"}
+		return m, m.requestExplain(req, v.group.QueryType)
+	case "h":
+		m.highlightMode = (m.highlightMode + 1) % 2
+		v.lastCursor = -1
+		v.updateDetail(m.highlightMode)
+		m.updateViewport()
+		return m, nil
+	case "pgup", "pgdown", "ctrl+u", "ctrl+d":
+		v.detail, cmd = v.detail.Update(msg)
+		return m, cmd
+	}
+	v.table, cmd = v.table.Update(msg)
+	v.updateDetail(m.highlightMode)
+	return m, cmd
+}
+
+// RenderExecutionsView renders the executions list, the selected execution and the key help
+func RenderExecutionsView(m Model) string {
+	v := m.executions
+	panelWidth := m.viewport.Width
+	count := fmt.Sprintf("%d executions", len(v.executions))
+	if len(v.executions) < v.group.Count {
+		count = fmt.Sprintf("%d of %d executions kept", len(v.executions), v.group.Count)
+	}
+	title := fmt.Sprintf("%s %s | %s | Order: %s",
+		lipgloss.NewStyle().Bold(true).Render(v.group.QueryType), v.group.Digest, count, executionOrders[v.order].Name)
+	listBox := leftStyle.BorderForeground(activeBorder).Width(panelWidth).Render(title + "\n" + v.table.View())
+	detailBox := rightStyle.BorderForeground(inactiveBorder).Width(panelWidth).Render(v.detail.View())
+	help := "[↑/↓] Select  [o] Order  [e/E] Explain/Analyze  [PgUp/PgDn] Scroll SQL  [h] Highlight  [Esc] Back  [q] Quit"
+	helpBox := lipgloss.NewStyle().Border(lipgloss.NormalBorder()).Width(panelWidth).Height(1).Render(help)
+	return appStyle.Margin(0, 0).Render(listBox + "\n" + detailBox + "\n" + helpBox)
+}
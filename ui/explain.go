@@ -0,0 +1,278 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"slowlog-tui/db"
+	"slowlog-tui/types"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// explainTimeout bounds EXPLAIN, which only plans the statement; EXPLAIN ANALYZE
+// runs it and is only bounded by Ctrl+C
+const explainTimeout = 30 * time.Second
+
+var (
+	fullScanStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#ff5f5f")).Bold(true)
+	planWarnStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#ff8700")).Bold(true)
+	planGoodStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#00d700"))
+	planTitleStyle = lipgloss.NewStyle().Bold(true)
+)
+
+// explainRequest is an execution of a group to explain
+type explainRequest struct {
+	digest  string
+	label   string // which execution, for the title
+	query   types.SlowQuery
+	analyze bool // EXPLAIN ANALYZE, which runs the query
+}
+
+// explainMsg delivers the result of an EXPLAIN
+type explainMsg struct {
+	explainRequest
+	gen  int
+	plan *db.PlanNode // EXPLAIN FORMAT=JSON
+	tree string       // EXPLAIN ANALYZE
+	err  error
+}
+
+// slowestExample returns the slowest kept execution of g
+func slowestExample(g types.GroupedQuery) (types.SlowQuery, bool) {
+	if len(g.Examples) == 0 {
+		return types.SlowQuery{}, false
+	}
+	slowest := g.Examples[0]
+	for _, q := range g.Examples[1:] {
+		if db.ParseTime(q.QueryTime) > db.ParseTime(slowest.QueryTime) {
+			slowest = q
+		}
+	}
+	return slowest, true
+}
+
+// requestExplain explains req on the source's server, asking for confirmation first
+// for EXPLAIN ANALYZE
+func (m *Model) requestExplain(req explainRequest, queryType string) tea.Cmd {
+	fail := func(text string) tea.Cmd {
+		m.statusText = text
+		m.statusColor = lipgloss.Color("#ff5f5f")
+		return flashStatus()
+	}
+	if _, ok := m.source.(db.ServerSource); !ok {
+		return fail("EXPLAIN needs a server connection (-source slow_log or perfschema)")
+	}
+	if !db.Explainable(queryType) {
+		return fail("Only SELECT, INSERT, REPLACE, UPDATE and DELETE can be explained")
+	}
+	if req.analyze {
+		if queryType != "SELECT" {
+			return fail("EXPLAIN ANALYZE executes the statement, only SELECT is supported")
+		}
+		m.pendingAnalyze = &req
+		m.statusText = fmt.Sprintf("EXPLAIN ANALYZE runs the query (logged at %s) on the server. Run it? [y/N]",
+			formatDuration(db.ParseTime(req.query.QueryTime)))
+		m.statusColor = lipgloss.Color("#ffaf00")
+		return nil
+	}
+	return m.runExplain(req)
+}
+
+// runExplain starts req in the background, cancelling a running EXPLAIN or schema lookup
+func (m *Model) runExplain(req explainRequest) tea.Cmd {
+	if m.inspectCancel != nil {
+		m.inspectCancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if !req.analyze {
+		ctx, cancel = context.WithTimeout(context.Background(), explainTimeout)
+	}
+	m.inspectGen++
+	m.inspectCancel = cancel
+	m.statusText = "Running EXPLAIN... [Ctrl+C] Cancel"
+	if req.analyze {
+		m.statusText = "Running EXPLAIN ANALYZE... [Ctrl+C] Cancel"
+	}
+	m.statusColor = lipgloss.Color("#00afff")
+
+	server := m.source.(db.ServerSource).Server()
+	gen := m.inspectGen
+	return func() tea.Msg {
+		defer cancel()
+		msg := explainMsg{explainRequest: req, gen: gen}
+		if req.analyze {
+			msg.tree, msg.err = server.ExplainAnalyze(ctx, req.query.DB, req.query.SQLText)
+		} else {
+			msg.plan, msg.err = server.Explain(ctx, req.query.DB, req.query.SQLText)
+		}
+		return msg
+	}
+}
+
+// cancelInspect stops the running EXPLAIN or schema lookup
+func (m *Model) cancelInspect() {
+	m.inspectCancel()
+	m.inspectCancel = nil
+	m.inspectGen++
+	m.statusText = "Cancelled"
+	m.statusColor = lipgloss.Color("#ffaf00")
+}
+
+// handleExplain shows a finished EXPLAIN in the preview
+func (m Model) handleExplain(msg explainMsg) (tea.Model, tea.Cmd) {
+	if msg.gen != m.inspectGen {
+		return m, nil // cancelled or superseded
+	}
+	m.inspectCancel = nil
+	if msg.err != nil {
+		m.statusText = "EXPLAIN failed: " + msg.err.Error()
+		m.statusColor = lipgloss.Color("#ff5f5f")
+		return m, nil
+	}
+	for _, g := range m.allGroups {
+		if g.Digest == msg.digest {
+			m.explain = &msg
+			m.schema = nil
+			m.showPreview(g)
+			m.focus = focusPreview
+			m.table.Blur()
+			break
+		}
+	}
+	m.statusText = ""
+	m.statusColor = ""
+	return m, nil
+}
+
+// NewExplainPanel renders the plan of an EXPLAIN in the preview, followed by the
+// explained statement
+func NewExplainPanel(e explainMsg, g types.GroupedQuery, highlightMode HighlightMode, width, height int) viewport.Model {
+	var b strings.Builder
+	title := "EXPLAIN"
+	if e.analyze {
+		title = "EXPLAIN ANALYZE"
+	}
+	q := e.query
+	b.WriteString(fmt.Sprintf("%s of the %s | %s %s\n", planTitleStyle.Render(title), e.label, g.QueryType, g.Digest))
+	b.WriteString(fmt.Sprintf("DB: %s | Query time: %s | Rows examined: %d | Rows sent: %d | Started: %s\n",
+		q.DB, formatDuration(db.ParseTime(q.QueryTime)), q.RowsExamined, q.RowsSent, q.StartTime))
+
+	var problems []string
+	var tree string
+	if e.analyze {
+		tree, problems = renderAnalyzeTree(e.tree)
+	} else {
+		tree, problems = renderPlan(e.plan)
+	}
+	if len(problems) == 0 {
+		b.WriteString(planGoodStyle.Render("No full table scans, filesorts or temporary tables") + "\n")
+	} else {
+		b.WriteString(planWarnStyle.Render("Problems: "+strings.Join(problems, ", ")) + "\n")
+	}
+	b.WriteString("\n" + tree + "\n")
+	b.WriteString("[e] EXPLAIN again  [E] EXPLAIN ANALYZE  [Esc] Back to the group\n\n")
+	if highlightMode == HighlightSimple {
+		b.WriteString(HighlightSQL(q.SQLText))
+	} else {
+		b.WriteString(q.SQLText)
+	}
+	vp := viewport.New(width, height)
+	vp.SetContent(b.String())
+	return vp
+}
+
+// renderPlan draws the plan as a tree, one step per line with the columns of
+// tabular EXPLAIN, and lists the full scans, filesorts and temporary tables
+func renderPlan(root *db.PlanNode) (string, []string) {
+	var b strings.Builder
+	var problems []string
+	var walk func(n *db.PlanNode, prefix, childPrefix string)
+	walk = func(n *db.PlanNode, prefix, childPrefix string) {
+		line, style := formatPlanNode(n)
+		switch {
+		case n.FullScan():
+			problems = append(problems, fmt.Sprintf("full table scan on %s (~%d rows)", n.Table, n.Rows))
+		case n.Filesort():
+			problems = append(problems, "filesort")
+		}
+		if n.Temporary() {
+			problems = append(problems, "temporary table")
+		}
+		b.WriteString(prefix + style.Render(line) + "\n")
+		for i, c := range n.Children {
+			if i == len(n.Children)-1 {
+				walk(c, childPrefix+"└─ ", childPrefix+"   ")
+			} else {
+				walk(c, childPrefix+"├─ ", childPrefix+"│  ")
+			}
+		}
+	}
+	walk(root, "", "")
+	return b.String(), problems
+}
+
+// formatPlanNode renders one step and picks its style: red for full table scans,
+// orange for filesorts and temporary tables
+func formatPlanNode(n *db.PlanNode) (string, lipgloss.Style) {
+	var parts []string
+	if n.Operation == "table" {
+		parts = append(parts, "table "+n.Table, "type: "+n.AccessType)
+		key := n.Key
+		if key == "" {
+			key = "-"
+		}
+		parts = append(parts, "key: "+key)
+		if len(n.PossibleKeys) > 0 {
+			parts = append(parts, "possible: "+strings.Join(n.PossibleKeys, ","))
+		}
+		parts = append(parts, fmt.Sprintf("rows: %d", n.Rows), fmt.Sprintf("filtered: %.2f%%", n.Filtered))
+	} else {
+		op := strings.ReplaceAll(n.Operation, "_", " ")
+		if n.SelectID > 0 {
+			op += fmt.Sprintf(" #%d", n.SelectID)
+		}
+		parts = append(parts, op)
+		if n.Cost != "" {
+			parts = append(parts, "cost: "+n.Cost)
+		}
+	}
+	if len(n.Extra) > 0 {
+		parts = append(parts, strings.Join(n.Extra, "; "))
+	}
+	style := lipgloss.NewStyle()
+	switch {
+	case n.FullScan():
+		style = fullScanStyle
+	case n.Filesort() || n.Temporary():
+		style = planWarnStyle
+	}
+	return strings.Join(parts, "  "), style
+}
+
+// renderAnalyzeTree highlights the steps of an EXPLAIN ANALYZE tree like renderPlan
+func renderAnalyzeTree(tree string) (string, []string) {
+	var b strings.Builder
+	var problems []string
+	for _, line := range strings.Split(strings.TrimRight(tree, "\n"), "\n") {
+		step := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "->"))
+		switch {
+		case strings.HasPrefix(step, "Table scan on "):
+			table, _, _ := strings.Cut(strings.TrimPrefix(step, "Table scan on "), " ")
+			problems = append(problems, "full table scan on "+table)
+			line = fullScanStyle.Render(line)
+		case strings.HasPrefix(step, "Sort"):
+			problems = append(problems, "filesort")
+			line = planWarnStyle.Render(line)
+		case strings.HasPrefix(step, "Materialize") || strings.Contains(step, "temporary table"):
+			problems = append(problems, "temporary table")
+			line = planWarnStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String(), problems
+}
@@ -0,0 +1,286 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"slowlog-tui/types"
+)
+
+// Filter is a parsed filter expression. Every term must match for a group to be shown.
+//
+//	orders                 substring of the normalized SQL (case-insensitive)
+//	/join.*orders/         regular expression on the normalized SQL (also re:...)
+//	db:shop type:SELECT    field match; % and _ are LIKE wildcards, \% and \_ literals
+//	table:orders user:app@%  digest:0xF1E3
+//	table=orders           table exactly as written in the statement: not shop.orders
+//	time>2s count>=10      numeric comparison: > >= < <= = !=
+//	-type:SELECT           a leading - negates a term
+type Filter struct {
+	Raw   string
+	terms []filterTerm
+}
+
+type filterTerm struct {
+	negate bool
+	match  func(g types.GroupedQuery) bool
+}
+
+// filterFields lists the field: predicates, for the help text and error messages
+var filterFields = []string{"db", "type", "table", "user", "host", "digest", "re"}
+
+// numericFields maps comparison fields to the group metric they test
+var numericFields = map[string]struct {
+	value    func(g types.GroupedQuery) float64
+	duration bool // value accepts a unit: 500ms, 2s, 1m
+}{
+	"count":    {func(g types.GroupedQuery) float64 { return float64(g.Count) }, false},
+	"time":     {func(g types.GroupedQuery) float64 { return g.AvgQueryTime }, true},
+	"p95":      {func(g types.GroupedQuery) float64 { return g.QueryTimeStats.P95 }, true},
+	"max":      {func(g types.GroupedQuery) float64 { return g.QueryTimeStats.Max }, true},
+	"total":    {func(g types.GroupedQuery) float64 { return g.TotalQueryTime }, true},
+	"lock":     {func(g types.GroupedQuery) float64 { return g.LockTimeStats.Avg }, true},
+	"examined": {func(g types.GroupedQuery) float64 { return g.AvgRowsExamined }, false},
+	"sent":     {func(g types.GroupedQuery) float64 { return g.AvgRowsSent }, false},
+	"impact":   {func(g types.GroupedQuery) float64 { return g.ImpactPercent }, false},
+}
+
+var comparisonRe = regexp.MustCompile(`^([a-z0-9]+)(>=|<=|!=|>|<|=)(.+)$`)
+
+// ParseFilter parses a filter expression; an empty expression matches everything
+func ParseFilter(s string) (Filter, error) {
+	f := Filter{Raw: strings.TrimSpace(s)}
+	for _, word := range splitFilterWords(f.Raw) {
+		negate := false
+		if len(word) > 1 && word[0] == '-' {
+			negate = true
+			word = word[1:]
+		}
+		match, err := parseFilterTerm(word)
+		if err != nil {
+			return Filter{}, err
+		}
+		f.terms = append(f.terms, filterTerm{negate: negate, match: match})
+	}
+	return f, nil
+}
+
+func parseFilterTerm(word string) (func(g types.GroupedQuery) bool, error) {
+	// regular expression
+	pattern := ""
+	switch {
+	case len(word) > 2 && strings.HasPrefix(word, "/") && strings.HasSuffix(word, "/"):
+		pattern = word[1 : len(word)-1]
+	case strings.HasPrefix(word, "re:"):
+		pattern = word[3:]
+	}
+	if pattern != "" {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("bad regex: %v", err)
+		}
+		return func(g types.GroupedQuery) bool { return re.MatchString(g.NormalizedSQL) }, nil
+	}
+
+	// exact table reference
+	if len(word) > len("table=") && strings.EqualFold(word[:len("table=")], "table=") {
+		name := word[len("table="):]
+		return func(g types.GroupedQuery) bool {
+			for _, t := range g.Tables {
+				if strings.EqualFold(t.String(), name) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+
+	// numeric comparison; other words with an operator, such as id=?, are SQL
+	if m := comparisonRe.FindStringSubmatch(word); m != nil {
+		if field, ok := numericFields[m[1]]; ok {
+			want, err := parseFilterNumber(m[3], field.duration)
+			if err != nil {
+				return nil, err
+			}
+			op := m[2]
+			return func(g types.GroupedQuery) bool { return compare(field.value(g), op, want) }, nil
+		}
+	}
+
+	// field:value
+	if i := strings.Index(word, ":"); i > 0 {
+		field, value := strings.ToLower(word[:i]), word[i+1:]
+		like := likeMatcher(value)
+		switch field {
+		case "db":
+			// every execution's database counts, not only those of the kept examples
+			return func(g types.GroupedQuery) bool {
+				for name := range g.Databases {
+					if like(name) {
+						return true
+					}
+				}
+				return false
+			}, nil
+		case "type":
+			return func(g types.GroupedQuery) bool { return like(g.QueryType) }, nil
+		case "table":
+			return func(g types.GroupedQuery) bool {
+				for _, t := range g.Tables {
+					if like(t.Name) || like(t.String()) {
+						return true
+					}
+				}
+				return false
+			}, nil
+		case "user":
+			withHost := strings.Contains(value, "@")
+			return func(g types.GroupedQuery) bool {
+				for a := range g.Accounts {
+					if withHost && like(a.String()) || !withHost && like(a.User) {
+						return true
+					}
+				}
+				return false
+			}, nil
+		case "host":
+			return func(g types.GroupedQuery) bool {
+				for a := range g.Accounts {
+					if like(a.Host) {
+						return true
+					}
+				}
+				return false
+			}, nil
+		case "digest":
+			want := strings.ToUpper(strings.TrimPrefix(strings.ToLower(value), "0x"))
+			return func(g types.GroupedQuery) bool {
+				return strings.HasPrefix(strings.ToUpper(strings.TrimPrefix(strings.ToLower(g.Digest), "0x")), want)
+			}, nil
+		default:
+			return nil, fmt.Errorf("unknown field %q (use %s)", field, strings.Join(filterFields, ", "))
+		}
+	}
+
+	// plain substring
+	needle := strings.ToLower(word)
+	return func(g types.GroupedQuery) bool {
+		return strings.Contains(strings.ToLower(g.NormalizedSQL), needle)
+	}, nil
+}
+
+// Match reports whether the group satisfies every term of the filter
+func (f Filter) Match(g types.GroupedQuery) bool {
+	for _, t := range f.terms {
+		if t.match(g) == t.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// Active reports whether the filter has any terms
+func (f Filter) Active() bool {
+	return len(f.terms) > 0
+}
+
+// Apply returns the groups matching the filter, as a new slice
+func (f Filter) Apply(groups []types.GroupedQuery) []types.GroupedQuery {
+	out := make([]types.GroupedQuery, 0, len(groups))
+	for _, g := range groups {
+		if f.Match(g) {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+// splitFilterWords splits on whitespace, keeping "double quoted" runs together
+func splitFilterWords(s string) []string {
+	var words []string
+	var cur strings.Builder
+	inQuote := false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case (r == ' ' || r == '\t') && !inQuote:
+			if cur.Len() > 0 {
+				words = append(words, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		words = append(words, cur.String())
+	}
+	return words
+}
+
+// parseFilterNumber parses a comparison operand; durations accept Go units (500ms, 2s, 1m) and default to seconds
+func parseFilterNumber(s string, duration bool) (float64, error) {
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v, nil
+	}
+	if duration {
+		if d, err := time.ParseDuration(s); err == nil {
+			return d.Seconds(), nil
+		}
+	}
+	return 0, fmt.Errorf("bad number %q", s)
+}
+
+func compare(got float64, op string, want float64) bool {
+	switch op {
+	case ">":
+		return got > want
+	case ">=":
+		return got >= want
+	case "<":
+		return got < want
+	case "<=":
+		return got <= want
+	case "=":
+		return got == want
+	case "!=":
+		return got != want
+	}
+	return false
+}
+
+// likeMatcher compiles a SQL LIKE pattern (% and _ wildcards, \ escapes the next
+// character) into a case-insensitive matcher
+func likeMatcher(pattern string) func(s string) bool {
+	if !strings.ContainsAny(pattern, `%_\`) {
+		return func(s string) bool { return strings.EqualFold(pattern, s) }
+	}
+	var re strings.Builder
+	re.WriteString("(?is)^")
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			re.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '%':
+			re.WriteString(".*")
+		case r == '_':
+			re.WriteString(".")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	if escaped {
+		re.WriteString(regexp.QuoteMeta(`\`))
+	}
+	re.WriteString("$")
+	compiled := regexp.MustCompile(re.String())
+	return compiled.MatchString
+}
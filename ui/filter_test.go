@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+
+	"slowlog-tui/types"
+)
+
+func TestFilter(t *testing.T) {
+	byID := types.GroupedQuery{NormalizedSQL: "SELECT * FROM orders WHERE id=?", Count: 12, AvgQueryTime: 2.5,
+		Tables: []types.TableRef{{Name: "orders", Role: types.TableRead}}}
+	byStatus := types.GroupedQuery{NormalizedSQL: "SELECT * FROM shop.orders WHERE status!=?", Count: 3, AvgQueryTime: 0.2,
+		Tables: []types.TableRef{{Schema: "shop", Name: "orders", Role: types.TableRead}}}
+	groups := []types.GroupedQuery{byID, byStatus}
+
+	tests := []struct {
+		filter string
+		want   []string // NormalizedSQL of the matching groups
+	}{
+		{"", []string{byID.NormalizedSQL, byStatus.NormalizedSQL}},
+		{"count>=10", []string{byID.NormalizedSQL}},
+		{"time<1s", []string{byStatus.NormalizedSQL}},
+		{"-count>=10", []string{byStatus.NormalizedSQL}},
+
+		// words with an operator but no metric name are SQL substrings
+		{"id=?", []string{byID.NormalizedSQL}},
+		{"status!=?", []string{byStatus.NormalizedSQL}},
+		{"-id=?", []string{byStatus.NormalizedSQL}},
+		{"orders id=? count>1", []string{byID.NormalizedSQL}},
+
+		// table: matches the name with or without schema, table= the reference as written
+		{"table:orders", []string{byID.NormalizedSQL, byStatus.NormalizedSQL}},
+		{"table:shop.%", []string{byStatus.NormalizedSQL}},
+		{"table=orders", []string{byID.NormalizedSQL}},
+		{"table=SHOP.orders", []string{byStatus.NormalizedSQL}},
+		{"-table=orders", []string{byStatus.NormalizedSQL}},
+	}
+	for _, tt := range tests {
+		f, err := ParseFilter(tt.filter)
+		if err != nil {
+			t.Errorf("ParseFilter(%q): %v", tt.filter, err)
+			continue
+		}
+		var got []string
+		for _, g := range f.Apply(groups) {
+			got = append(got, g.NormalizedSQL)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ParseFilter(%q) matches %q, want %q", tt.filter, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"count>many", "time>2x", "nope:1", "/(/"} {
+		if _, err := ParseFilter(bad); err == nil {
+			t.Errorf("ParseFilter(%q) succeeded, want an error", bad)
+		}
+	}
+}
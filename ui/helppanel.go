@@ -5,20 +5,32 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
+var filterStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#00afff"))
+
 var HelpOptions = []struct{ Key, Desc string }{
 	{"↑/↓", "Scroll"},
 	{"↵", "Show Queries"},
 	{"Tab", "Switch panel"},
 	{"l", "Sort"},
+	{"/", "Filter"},
+	{"t", "Range"},
+	{"e", "Explain"},
+	{"d", "Schema"},
+	{"i", "Indexes"},
+	{"v", "Rollups"},
 	{"s", "Save queries"},
 	{"z", "Zoom"},
 	{"h", "HL-mode"},
 	{"q", "Quit"},
 }
 
-func RenderHelpPanel(highlightMode int, panelWidth int, status string, statusColor lipgloss.Color) string {
+// RenderHelpPanel renders the key help line. infoLine, when set, is the filter bar
+// being edited (shown instead of the key help) or the active filter and time range
+// (appended to it).
+func RenderHelpPanel(highlightMode int, panelWidth int, status string, statusColor lipgloss.Color, infoLine string, editingFilter bool) string {
 	highlightStatus := "[h] Highlight: "
 	switch highlightMode {
 	case 1:
@@ -32,10 +44,23 @@ func RenderHelpPanel(highlightMode int, panelWidth int, status string, statusCol
 		helpParts[i] = fmt.Sprintf("[%s] %s", opt.Key, opt.Desc)
 	}
 	helpText := strings.Join(helpParts, "  ") + "  " + highlightStatus
+	info := ""
+	if editingFilter {
+		helpText = infoLine
+	} else if infoLine != "" {
+		info = "  " + filterStyle.Render(infoLine)
+	}
 	if status == "" {
 		status = ""
 		statusColor = ""
 	}
+	// keep the line on one row: the key help gives way to the filter/range info and status
+	if avail := panelWidth - lipgloss.Width(status) - 5; lipgloss.Width(helpText+info) > avail {
+		helpText = ansi.Truncate(helpText, max(avail-lipgloss.Width(info), 0), "…")
+		helpText = ansi.Truncate(helpText+info, max(avail, 0), "…")
+	} else {
+		helpText += info
+	}
 	space := panelWidth - lipgloss.Width(helpText) - lipgloss.Width(status) - 4 // 4 for border padding
 	if space < 1 {
 		space = 1
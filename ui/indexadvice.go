@@ -0,0 +1,178 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"slowlog-tui/analyze"
+	"slowlog-tui/db"
+	"slowlog-tui/types"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// lowBenefit is the share of examined rows not sent below which an index is
+// unlikely to make a group much faster
+const lowBenefit = 0.5
+
+// NewIndexPanel renders the indexes proposed for the tables of a group with the
+// estimated benefit and the statements creating them
+func NewIndexPanel(s schemaMsg, g types.GroupedQuery, highlightMode HighlightMode, width, height int) viewport.Model {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s for %s %s | Avg: %.0f rows examined, %.0f sent\n",
+		planTitleStyle.Render("Index advice"), g.QueryType, g.Digest, g.AvgRowsExamined, g.AvgRowsSent))
+	b.WriteString("[s] Save statements  [y] Copy  [i] Read again  [Esc] Back to the group\n\n")
+	b.WriteString(formatBenefit(g) + "\n")
+
+	advice := analyze.RecommendIndexes(g, s.tables)
+	for _, t := range s.tables {
+		b.WriteString("\n" + planTitleStyle.Render("── "+t.String()+" ") + "\n")
+		if t.Err != nil {
+			b.WriteString(fullScanStyle.Render("Error: "+t.Err.Error()) + "\n")
+			continue
+		}
+		a, ok := adviceFor(advice, t.TableName)
+		if !ok {
+			b.WriteString("No filtered, joined or sorted columns\n")
+			continue
+		}
+		b.WriteString("Columns: " + formatKeyParts(a.Parts) + "\n")
+		existing := indexColumns(t, a.Existing)
+		switch {
+		case a.Statement == "":
+			b.WriteString(planGoodStyle.Render(fmt.Sprintf("Already served by %s (%s)", a.Existing, existing)) + "\n")
+			continue
+		case a.Replaces:
+			b.WriteString(fmt.Sprintf("Extends %s (%s), which can be dropped once the new index exists\n", a.Existing, existing))
+		case a.Existing != "":
+			b.WriteString(fmt.Sprintf("%s (%s) serves %d of %d columns\n", a.Existing, existing, a.Served, len(a.Parts)))
+		default:
+			b.WriteString(planWarnStyle.Render("No index serves these columns") + "\n")
+		}
+		if highlightMode == HighlightSimple {
+			b.WriteString(HighlightSQL(a.Statement))
+		} else {
+			b.WriteString(a.Statement)
+		}
+		b.WriteString("\n")
+	}
+	vp := viewport.New(width, height)
+	vp.SetContent(b.String())
+	return vp
+}
+
+// formatBenefit tells how much of a group's reads an index could avoid
+func formatBenefit(g types.GroupedQuery) string {
+	rows, seconds, ok := analyze.Benefit(g)
+	if !ok {
+		return "Benefit: not estimated, the statement sends no rows to compare the rows examined with"
+	}
+	line := fmt.Sprintf("Benefit: %.1f%% of the rows examined are not sent; an index reading only the rows sent could save up to %s of %s total",
+		rows*100, formatDuration(seconds), formatDuration(g.TotalQueryTime))
+	if rows < lowBenefit {
+		return line + "\n" + planWarnStyle.Render("Most rows examined are sent: an index is unlikely to make this much faster")
+	}
+	return line
+}
+
+// formatKeyParts lists the columns of a proposed index with how the statement uses them
+func formatKeyParts(parts []analyze.KeyPart) string {
+	s := make([]string, len(parts))
+	for i, p := range parts {
+		use := string(p.Use)
+		if p.Desc {
+			use += " desc"
+		}
+		s[i] = fmt.Sprintf("%s (%s)", p, use)
+	}
+	return strings.Join(s, ", ")
+}
+
+func adviceFor(advice []analyze.IndexAdvice, t db.TableName) (analyze.IndexAdvice, bool) {
+	for _, a := range advice {
+		if a.Table == t {
+			return a, true
+		}
+	}
+	return analyze.IndexAdvice{}, false
+}
+
+// indexColumns lists the columns of the named index of t
+func indexColumns(t db.TableSchema, name string) string {
+	for _, ix := range t.Indexes {
+		if ix.Name == name {
+			cols := make([]string, len(ix.Columns))
+			for i, c := range ix.Columns {
+				cols[i] = c.Name
+			}
+			return strings.Join(cols, ", ")
+		}
+	}
+	return ""
+}
+
+// indexStatements returns the statements proposed for the group of the index
+// advice being shown, one per line, or "" if there are none
+func (m Model) indexStatements() (types.GroupedQuery, string) {
+	if m.schema == nil || !m.schema.advise {
+		return types.GroupedQuery{}, ""
+	}
+	for _, g := range m.allGroups {
+		if g.Digest != m.schema.digest {
+			continue
+		}
+		var lines []string
+		for _, a := range analyze.RecommendIndexes(g, m.schema.tables) {
+			if a.Statement != "" {
+				lines = append(lines, a.Statement)
+			}
+		}
+		if len(lines) == 0 {
+			return g, ""
+		}
+		return g, strings.Join(lines, "\n") + "\n"
+	}
+	return types.GroupedQuery{}, ""
+}
+
+// saveIndexAdvice writes the proposed statements to index_<digest>.sql
+func (m *Model) saveIndexAdvice() tea.Cmd {
+	g, statements := m.indexStatements()
+	if statements == "" {
+		m.statusText = "No index to add"
+		m.statusColor = lipgloss.Color("#ff8700")
+		return flashStatus()
+	}
+	name := fmt.Sprintf("index_%s.sql", strings.TrimPrefix(g.Digest, "0x"))
+	content := fmt.Sprintf("-- digest: %s\n-- fingerprint: %s\n%s", g.Digest, g.NormalizedSQL, statements)
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		m.statusText = "Saving failed: " + err.Error()
+		m.statusColor = lipgloss.Color("#ff5f5f")
+		return flashStatus()
+	}
+	m.statusText = "Statements saved to " + name
+	m.statusColor = lipgloss.Color("#00d700")
+	return flashStatus()
+}
+
+// copyIndexAdvice puts the proposed statements on the system clipboard
+func (m *Model) copyIndexAdvice() tea.Cmd {
+	_, statements := m.indexStatements()
+	if statements == "" {
+		m.statusText = "No index to add"
+		m.statusColor = lipgloss.Color("#ff8700")
+		return flashStatus()
+	}
+	if err := clipboard.WriteAll(statements); err != nil {
+		m.statusText = "Copying failed: " + err.Error() + " ([s] saves them instead)"
+		m.statusColor = lipgloss.Color("#ff5f5f")
+		return flashStatus()
+	}
+	m.statusText = "Statements copied!"
+	m.statusColor = lipgloss.Color("#00d700")
+	return flashStatus()
+}
@@ -0,0 +1,160 @@
+package ui
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"slowlog-tui/db"
+	"slowlog-tui/types"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// loadBatchInterval is how often a running load publishes the groups read so far
+const loadBatchInterval = 500 * time.Millisecond
+
+// loadProgressMsg carries a snapshot of the groups of a running load
+type loadProgressMsg struct {
+	gen      int // load generation, to drop messages of superseded loads
+	groups   []types.GroupedQuery
+	records  int
+	caughtUp bool // watch mode: the records that existed at start have been read
+	done     bool
+	err      error
+}
+
+// loader reads a source on its own goroutine, publishing the groups in batches
+// so the UI can show them while the load is running. In watch mode it keeps
+// following the source until cancelled.
+type loader struct {
+	gen      int
+	opts     db.LoadOptions
+	cancel   context.CancelFunc
+	updates  chan loadProgressMsg
+	started  time.Time
+	records  int  // records read so far
+	watching bool // caught up and waiting for new records
+
+	mu        sync.Mutex // guards agg
+	agg       *db.Aggregator
+	published int        // agg.Len() at the last snapshot
+	sendMu    sync.Mutex // keeps snapshots in order
+}
+
+// startLoader starts reading src with opts
+func startLoader(gen int, src db.Source, opts db.LoadOptions) *loader {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &loader{gen: gen, opts: opts, cancel: cancel, updates: make(chan loadProgressMsg), started: time.Now()}
+	go l.run(ctx, src)
+	return l
+}
+
+func (l *loader) run(ctx context.Context, src db.Source) {
+	defer close(l.updates) // releases a pending wait once the load was cancelled
+
+	if gs, ok := src.(db.GroupSource); ok {
+		l.runGroups(ctx, gs)
+		return
+	}
+
+	l.agg = db.NewAggregator(l.opts.MaxExamples)
+	done := make(chan error, 1)
+	go func() { done <- l.stream(ctx, src) }()
+
+	interval := loadBatchInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			l.publish(ctx, loadProgressMsg{done: true, err: err})
+			return
+		case <-ticker.C:
+			// snapshots recompute the statistics of every changed group, so keep them
+			// to a fraction of the load time on big sources
+			start := time.Now()
+			l.publish(ctx, loadProgressMsg{})
+			if next := max(loadBatchInterval, 4*time.Since(start)); next != interval {
+				interval = next
+				ticker.Reset(interval)
+			}
+		}
+	}
+}
+
+// stream feeds the source into the aggregator, following it in watch mode
+func (l *loader) stream(ctx context.Context, src db.Source) error {
+	add := func(q types.SlowQuery) error {
+		l.mu.Lock()
+		l.agg.Add(q)
+		l.mu.Unlock()
+		return nil
+	}
+	if f, ok := src.(db.Follower); ok && l.opts.Watch > 0 {
+		caughtUp := func() { l.publish(ctx, loadProgressMsg{caughtUp: true}) }
+		return f.Follow(ctx, l.opts.Range, l.opts.Watch, add, caughtUp)
+	}
+	return src.Stream(ctx, l.opts.Range, add)
+}
+
+// runGroups reads a source that groups on the server, again every poll in watch mode
+func (l *loader) runGroups(ctx context.Context, gs db.GroupSource) {
+	for {
+		groups, err := gs.Groups(ctx, l.opts.Range)
+		records := 0
+		for _, g := range groups {
+			records += g.Count
+		}
+		msg := loadProgressMsg{groups: groups, records: records, caughtUp: true}
+		if err != nil || l.opts.Watch <= 0 {
+			msg.done, msg.err = true, err
+			l.send(ctx, msg)
+			return
+		}
+		if !l.send(ctx, msg) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(l.opts.Watch):
+		}
+	}
+}
+
+// publish sends a snapshot of the aggregated groups, unless nothing changed since
+// the last one and msg has no news of its own
+func (l *loader) publish(ctx context.Context, msg loadProgressMsg) bool {
+	l.sendMu.Lock()
+	defer l.sendMu.Unlock()
+	l.mu.Lock()
+	if l.agg.Len() == l.published && !msg.done && !msg.caughtUp {
+		l.mu.Unlock()
+		return true
+	}
+	msg.groups = l.agg.Groups()
+	msg.records = l.agg.Len()
+	l.published = msg.records
+	l.mu.Unlock()
+	return l.send(ctx, msg)
+}
+
+// send delivers msg to the UI, giving up when the load is cancelled
+func (l *loader) send(ctx context.Context, msg loadProgressMsg) bool {
+	msg.gen = l.gen
+	select {
+	case l.updates <- msg:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// wait returns a command that delivers the next message of the load; a closed
+// channel yields a zero message, which matches no generation
+func (l *loader) wait() tea.Cmd {
+	return func() tea.Msg {
+		return <-l.updates
+	}
+}
@@ -1,12 +1,18 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
+	"slowlog-tui/analyze"
+	"slowlog-tui/db"
 	"slowlog-tui/types"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
@@ -38,9 +44,32 @@ const (
 	HighlightSimple
 )
 
+// baseSortColumns are the sort modal entries handled by SortGroups; extended
+// attribute columns are appended after them
+var baseSortColumns = []string{
+	"Count", "Avg Time", "Avg Examined", "Avg Sent", "Type", "DB", "Table",
+	"Median Time", "P95 Time", "P99 Time", "Max Time", "StdDev Time", "P95 Examined", "P95 Sent",
+	"Avg Lock", "Max Lock", "Total Lock", "Impact",
+}
+
+// defaultSortColumn ranks groups by total time spent, like pt-query-digest
+var defaultSortColumn = sortColumnIndex("Impact")
+
+// sortColumnIndex returns the position of a sort modal entry in baseSortColumns
+func sortColumnIndex(name string) int {
+	i := slices.Index(baseSortColumns, name)
+	if i < 0 {
+		panic("unknown sort column " + name)
+	}
+	return i
+}
+
 type Model struct {
 	table          table.Model
 	allGroups      []types.GroupedQuery
+	attrColumns    []string // extended attributes shown as table columns
+	analyzer       *analyze.Analyzer
+	findings       map[string][]analyze.Finding // anti-patterns per digest
 	filteredGroups []types.GroupedQuery
 	viewport       viewport.Model
 	focus          focusArea
@@ -48,6 +77,7 @@ type Model struct {
 	lastCursor     int
 	statusText     string         // for flash/status messages
 	statusColor    lipgloss.Color // color for status message
+	previewDigest  string         // group shown in the preview
 	highlightMode  HighlightMode  // 0=off, 1=simple
 	zoomed         bool           // fullscreen preview mode
 
@@ -58,42 +88,300 @@ type Model struct {
 	sortColumns     []string
 	sortOrder       int // 0=asc, 1=desc
 	sortModalFocus  int // 0=columns, 1=order
+
+	// Filter bar state
+	filterInput textinput.Model
+	filtering   bool // filter bar has keyboard focus
+	filter      Filter
+	tableHeight int
+
+	// Time range state; the source is re-read when the range changes
+	source        db.Source
+	load          db.LoadOptions
+	showTimeModal bool
+	timeInputs    [2]textinput.Model // Since, Until
+	timeFocus     int
+	timeError     string
+
+	// Background load of the source
+	loader   *loader // nil when no load is running
+	loadGen  int
+	spinner  spinner.Model
+	baseline map[string]int // watch mode: executions per digest when the load caught up
+
+	// Executions drill-down of the previewed group
+	showExecutions bool
+	executions     executionsView
+
+	// Groups rolled up by table, database or query type
+	showRollup bool
+	rollup     rollupView
+
+	// EXPLAIN, table schemas or index advice of the previewed group, shown instead of the group
+	explain        *explainMsg        // nil if not shown
+	schema         *schemaMsg         // nil if not shown
+	inspectCancel  context.CancelFunc // running EXPLAIN or schema lookup, nil if none
+	inspectGen     int
+	pendingAnalyze *explainRequest // EXPLAIN ANALYZE waiting for confirmation
 }
 
-func NewModel(groups []types.GroupedQuery) Model {
+// NewModel creates the UI and starts reading src with opts; groups show up as
+// they are read and are checked by analyzer
+func NewModel(src db.Source, opts db.LoadOptions, analyzer *analyze.Analyzer) Model {
 	m := Model{
-		allGroups:      groups,
-		focus:          focusTable,
-		lastCursor:     -1,
-		highlightMode:  HighlightSimple, // default to simple highlighter
-		sortColumn:     0,
-		sortColumns:    []string{"Count", "Avg Time", "Avg Examined", "Avg Sent", "Type", "DB", "Table"},
-		sortOrder:      0,
-		sortModalFocus: 0,
+		source:          src,
+		load:            opts,
+		analyzer:        analyzer,
+		focus:           focusTable,
+		lastCursor:      -1,
+		highlightMode:   HighlightSimple, // default to simple highlighter
+		sortColumn:      defaultSortColumn,
+		sortModalCursor: defaultSortColumn,
+		sortColumns:     baseSortColumns,
+		sortOrder:       0,
+		sortModalFocus:  0,
 	}
+	m.spinner = spinner.New(spinner.WithSpinner(spinner.Dot))
+	m.startLoad(opts)
 	m.viewport = viewport.New(1, 20)
+	m.filterInput = textinput.New()
+	m.filterInput.Prompt = "/"
+	m.filterInput.Placeholder = "text, /regex/, db:shop type:SELECT table:orders user:app@% time>2s count>=10"
 	return m
 }
 
-// Remove table logic from applyFilters, use tablepanel.go
+// applyFilters rebuilds the table from the groups matching the filter, in the selected order
 func (m *Model) applyFilters(tableWidth int) {
-	m.filteredGroups = m.allGroups
-	SortGroups(m.filteredGroups, m.sortColumn, m.sortOrder)
-	m.table = NewTablePanel(m.filteredGroups, tableWidth, m.height/2-2)
+	m.filteredGroups = m.filter.Apply(m.allGroups)
+	if m.sortColumn >= len(baseSortColumns) {
+		SortGroupsByAttribute(m.filteredGroups, m.sortColumns[m.sortColumn], m.sortOrder)
+	} else {
+		SortGroups(m.filteredGroups, m.sortColumn, m.sortOrder)
+	}
+	m.table = NewTablePanel(m.filteredGroups, m.attrColumns, m.baseline, m.findings, tableWidth, m.tableHeight)
+	m.lastCursor = -1
+}
+
+// updateFilterBar handles keys while the filter bar has focus, re-filtering on every change
+func (m Model) updateFilterBar(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.filtering = false
+		m.filterInput.Blur()
+		m.clearFilter()
+		return m, nil
+	case "enter":
+		m.filtering = false
+		m.filterInput.Blur()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.filterInput, cmd = m.filterInput.Update(msg)
+	f, err := ParseFilter(m.filterInput.Value())
+	if err != nil {
+		m.statusText = err.Error()
+		m.statusColor = lipgloss.Color("#ff5f5f")
+		return m, cmd
+	}
+	m.statusText = ""
+	m.statusColor = ""
+	m.filter = f
+	m.applyFilters(m.viewport.Width)
+	return m, cmd
+}
+
+// setGroups replaces the groups with a newer read of the source, keeping filter,
+// sort, the selected group and the previewed group
+func (m *Model) setGroups(groups []types.GroupedQuery) {
+	selected := ""
+	if i := m.table.Cursor(); i >= 0 && i < len(m.filteredGroups) {
+		selected = m.filteredGroups[i].Digest
+	}
+	sortName := m.sortColumns[m.sortColumn]
+
+	m.allGroups = groups
+	m.attrColumns = PresentAttributes(groups)
+	m.findings = make(map[string][]analyze.Finding)
+	for _, g := range groups {
+		if f := m.analyzer.Check(g); len(f) > 0 {
+			m.findings[g.Digest] = f
+		}
+	}
+	m.sortColumns = append(append([]string{}, baseSortColumns...), m.attrColumns...)
+	m.sortColumn = defaultSortColumn
+	for i, name := range m.sortColumns {
+		if name == sortName {
+			m.sortColumn = i
+		}
+	}
+	m.sortModalCursor = m.sortColumn
+	m.applyFilters(m.viewport.Width)
+	if m.showRollup {
+		m.rollup.setGroups(m.filteredGroups)
+	}
+
+	for i, g := range m.filteredGroups {
+		if g.Digest == selected {
+			m.table.SetCursor(i)
+		}
+	}
+	if m.previewDigest == "" {
+		return
+	}
+	for _, g := range m.filteredGroups {
+		if g.Digest == m.previewDigest {
+			offset := m.viewport.YOffset
+			m.showPreview(g)
+			m.viewport.SetYOffset(offset)
+			return
+		}
+	}
+	m.previewDigest = ""
+	m.viewport.SetContent("")
+}
+
+// startLoad (re-)reads the source with opts in the background, cancelling a running load
+func (m *Model) startLoad(opts db.LoadOptions) tea.Cmd {
+	if m.source == nil {
+		return nil
+	}
+	if m.loader != nil {
+		m.loader.cancel()
+	}
+	m.loadGen++
+	m.load = opts
+	m.baseline = nil
+	m.loader = startLoader(m.loadGen, m.source, opts)
+	return tea.Batch(m.loader.wait(), m.spinner.Tick)
+}
+
+// stopLoad cancels the running load, keeping the groups read so far
+func (m *Model) stopLoad() {
+	m.loader.cancel()
+	m.statusText = fmt.Sprintf("Load stopped after %d records", m.loader.records)
+	if m.loader.watching {
+		m.statusText = fmt.Sprintf("Stopped watching after %d records", m.loader.records)
+	}
+	m.statusColor = lipgloss.Color("#ffaf00")
+	m.loader = nil
+}
+
+// loadProgress describes the running load for the help panel
+func (m Model) loadProgress() string {
+	l := m.loader
+	if l.watching {
+		grown := 0
+		for _, g := range m.allGroups {
+			if formatGrowth(g, m.baseline) != "" {
+				grown++
+			}
+		}
+		return fmt.Sprintf("%s Watching %s every %s: %d records, %d groups, %d new/growing [Ctrl+C] Stop",
+			m.spinner.View(), m.source.Name(), l.opts.Watch, l.records, len(m.allGroups), grown)
+	}
+	elapsed := time.Since(l.started).Truncate(time.Second)
+	return fmt.Sprintf("%s Reading %s: %d records, %d groups, %s [Ctrl+C] Stop",
+		m.spinner.View(), m.source.Name(), l.records, len(m.allGroups), elapsed)
+}
+
+// handleLoadProgress shows the groups of a running load and waits for its next batch
+func (m Model) handleLoadProgress(msg loadProgressMsg) (tea.Model, tea.Cmd) {
+	if m.loader == nil || msg.gen != m.loader.gen {
+		return m, nil // superseded or stopped load
+	}
+	m.loader.records = msg.records
+	caughtUp := msg.caughtUp && m.load.Watch > 0 && !m.loader.watching
+	if caughtUp {
+		m.loader.watching = true
+		m.baseline = make(map[string]int, len(msg.groups))
+		for _, g := range msg.groups {
+			m.baseline[g.Digest] = g.Count
+		}
+	}
+	m.setGroups(msg.groups)
+	if caughtUp {
+		m.statusText = fmt.Sprintf("Loaded %d groups from %d records, watching for new queries", len(msg.groups), msg.records)
+		m.statusColor = lipgloss.Color("#00d700")
+	}
+	if !msg.done {
+		return m, m.loader.wait()
+	}
+	m.loader = nil
+	switch {
+	case msg.err != nil:
+		m.statusText = "Error loading slow log: " + msg.err.Error()
+		m.statusColor = lipgloss.Color("#ff5f5f")
+		return m, nil
+	case len(msg.groups) == 0:
+		m.statusText = "No slow queries found in " + m.source.Name()
+		m.statusColor = lipgloss.Color("#ffaf00")
+		return m, nil
+	}
+	m.statusText = fmt.Sprintf("Loaded %d groups from %d records", len(msg.groups), msg.records)
+	m.statusColor = lipgloss.Color("#00d700")
+	return m, flashStatus()
+}
+
+func (m *Model) clearFilter() {
+	m.filterInput.SetValue("")
+	m.filter = Filter{}
+	m.statusText = ""
+	m.statusColor = ""
+	m.applyFilters(m.viewport.Width)
 }
 
 func (m *Model) updateViewport() {
 	cursor := m.table.Cursor()
 	if cursor >= 0 && cursor < len(m.filteredGroups) {
-		g := m.filteredGroups[cursor]
-		// Use NewPreviewPanel for preview logic
-		m.viewport = NewPreviewPanel(g, int(m.highlightMode), m.viewport.Width, m.viewport.Height)
+		m.showPreview(m.filteredGroups[cursor])
 		m.statusText = ""
 		m.statusColor = ""
 	}
 }
 
+// showPreview renders g in the preview panel, or its plan or table schemas if they
+// were looked up
+func (m *Model) showPreview(g types.GroupedQuery) {
+	if m.explain != nil && m.explain.digest != g.Digest {
+		m.explain = nil
+	}
+	if m.schema != nil && m.schema.digest != g.Digest {
+		m.schema = nil
+	}
+	switch {
+	case m.explain != nil:
+		m.viewport = NewExplainPanel(*m.explain, g, m.highlightMode, m.viewport.Width, m.viewport.Height)
+	case m.schema != nil && m.schema.advise:
+		m.viewport = NewIndexPanel(*m.schema, g, m.highlightMode, m.viewport.Width, m.viewport.Height)
+	case m.schema != nil:
+		m.viewport = NewSchemaPanel(*m.schema, g, m.highlightMode, m.viewport.Width, m.viewport.Height)
+	default:
+		m.viewport = NewPreviewPanel(g, m.baseline, m.findings[g.Digest], int(m.highlightMode), m.viewport.Width, m.viewport.Height)
+	}
+	m.previewDigest = g.Digest
+}
+
+// explainSelected explains the slowest kept execution of the selected group
+func (m *Model) explainSelected(analyze bool) tea.Cmd {
+	i := m.table.Cursor()
+	if i < 0 || i >= len(m.filteredGroups) {
+		return nil
+	}
+	g := m.filteredGroups[i]
+	q, ok := slowestExample(g)
+	if !ok {
+		return nil
+	}
+	req := explainRequest{digest: g.Digest, label: "slowest kept execution", query: q, analyze: analyze}
+	return m.requestExplain(req, g.QueryType)
+}
+
 func (m Model) Init() tea.Cmd {
+	if m.loader != nil {
+		return tea.Batch(textinput.Blink, m.loader.wait(), m.spinner.Tick)
+	}
 	return textinput.Blink
 }
 
@@ -102,9 +390,60 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" && m.inspectCancel != nil {
+			m.cancelInspect()
+			return m, nil
+		}
+		if msg.String() == "ctrl+c" && m.loader != nil {
+			m.stopLoad()
+			return m, nil
+		}
+		if m.pendingAnalyze != nil {
+			req := *m.pendingAnalyze
+			m.pendingAnalyze = nil
+			if msg.String() == "y" || msg.String() == "Y" {
+				return m, m.runExplain(req)
+			}
+			m.statusText = "EXPLAIN ANALYZE cancelled"
+			m.statusColor = lipgloss.Color("#ffaf00")
+			return m, flashStatus()
+		}
+		if m.filtering {
+			return m.updateFilterBar(msg)
+		}
+		if m.showTimeModal {
+			return m.updateTimeModal(msg)
+		}
+		if m.showExecutions {
+			return m.updateExecutions(msg)
+		}
+		if m.showRollup {
+			return m.updateRollup(msg)
+		}
 		switch msg.String() {
 		case "ctrl+c", "q":
 			return m, tea.Quit
+		case "/":
+			if !m.showSortModal {
+				m.filtering = true
+				m.focus = focusTable
+				m.table.Focus()
+				return m, m.filterInput.Focus()
+			}
+		case "esc":
+			if !m.showSortModal && (m.explain != nil || m.schema != nil) {
+				m.explain, m.schema = nil, nil
+				for _, g := range m.filteredGroups {
+					if g.Digest == m.previewDigest {
+						m.showPreview(g)
+					}
+				}
+				return m, nil
+			}
+			if !m.showSortModal && m.filter.Active() {
+				m.clearFilter()
+				return m, nil
+			}
 		case "tab":
 			if m.focus == focusTable {
 				m.focus = focusPreview
@@ -114,20 +453,40 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.table.Focus()
 			}
 		case "s":
+			if m.schema != nil && m.schema.advise {
+				return m, m.saveIndexAdvice()
+			}
 			i := m.table.Cursor()
 			if i >= 0 && i < len(m.filteredGroups) {
-				f, _ := os.Create(fmt.Sprintf("query_%d.sql", i+1))
-				defer f.Close()
-				f.WriteString(m.filteredGroups[i].Examples[0].SQLText)
+				g := m.filteredGroups[i]
+				name := fmt.Sprintf("query_%d.sql", i+1)
+				if g.Digest != "" {
+					name = fmt.Sprintf("query_%s.sql", strings.TrimPrefix(g.Digest, "0x"))
+				}
+				content := fmt.Sprintf("-- digest: %s\n-- fingerprint: %s\n", g.Digest, g.NormalizedSQL)
+				if len(g.Examples) > 0 {
+					content += g.Examples[0].SQLText
+				}
+				if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+					m.statusText = "Saving failed: " + err.Error()
+					m.statusColor = lipgloss.Color("#ff5f5f")
+					return m, flashStatus()
+				}
 				m.statusText = "Query saved!"
 				m.statusColor = lipgloss.Color("#00d700") // green for success
 				return m, flashStatus()
 			}
 		case "enter":
+			if m.showSortModal {
+				break
+			}
 			if m.focus == focusTable {
 				m.lastCursor = m.table.Cursor()
 				m.updateViewport()
 				m.viewport.GotoTop() // reset scroll position to top
+			} else {
+				m.openExecutions()
+				return m, nil
 			}
 		case "h":
 			m.highlightMode = (m.highlightMode + 1) % 2
@@ -144,6 +503,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "l":
 			m.showSortModal = true
 			return m, nil
+		case "t":
+			if !m.showSortModal && m.source != nil {
+				return m, m.openTimeModal()
+			}
+		case "e", "E":
+			if !m.showSortModal {
+				return m, m.explainSelected(msg.String() == "E")
+			}
+		case "d", "i":
+			if !m.showSortModal {
+				return m, m.describeSelected(msg.String() == "i")
+			}
+		case "y":
+			if !m.showSortModal && m.schema != nil && m.schema.advise {
+				return m, m.copyIndexAdvice()
+			}
+		case "v":
+			if !m.showSortModal {
+				m.openRollup(0)
+				return m, nil
+			}
 		}
 		if m.showSortModal {
 			switch msg.String() {
@@ -188,11 +568,29 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		tablePanelHeight := remainingHeight / 2
 		previewPanelHeight := remainingHeight - tablePanelHeight
 
+		m.tableHeight = tablePanelHeight
 		m.applyFilters(panelWidth)
-		m.table.SetHeight(tablePanelHeight)
 		m.viewport.Width = panelWidth
 		m.viewport.Height = previewPanelHeight
 		m.lastCursor = -1 // force viewport update
+		if m.showExecutions {
+			m.executions.resize(panelWidth, m.height, m.highlightMode)
+		}
+		if m.showRollup {
+			m.rollup.resize(panelWidth, m.height)
+		}
+	case loadProgressMsg:
+		return m.handleLoadProgress(msg)
+	case explainMsg:
+		return m.handleExplain(msg)
+	case schemaMsg:
+		return m.handleSchema(msg)
+	case spinner.TickMsg:
+		if m.loader == nil {
+			return m, nil
+		}
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
 	case flashStatusMsg:
 		// Clear status after a short delay
 		return m, tea.Tick(1500*time.Millisecond, func(t time.Time) tea.Msg {
@@ -218,6 +616,15 @@ func (m Model) View() string {
 	if m.showSortModal {
 		return RenderSortModalView(m)
 	}
+	if m.showTimeModal {
+		return RenderTimeRangeModalView(m)
+	}
+	if m.showExecutions {
+		return RenderExecutionsView(m)
+	}
+	if m.showRollup {
+		return RenderRollupView(m)
+	}
 	if m.zoomed {
 		return RenderZoomedPreviewView(m)
 	}
@@ -2,23 +2,74 @@ package ui
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
+	"slowlog-tui/analyze"
 	"slowlog-tui/types"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	"github.com/charmbracelet/lipgloss"
 )
 
-// PreviewPanel handles the SQL preview/viewport logic
-func NewPreviewPanel(g types.GroupedQuery, highlightMode int, width, height int) viewport.Model {
-	header := fmt.Sprintf("%s | %d queries | Avg: %.2fs, %.0f rows examined, %.0f sent\n\n",
+var lockWarningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ff8700")).Bold(true)
+
+var growthStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#00d700")).Bold(true)
+
+// PreviewPanel handles the SQL preview/viewport logic. baseline is the table's
+// watch mode baseline, nil when not watching; findings are the group's anti-patterns.
+func NewPreviewPanel(g types.GroupedQuery, baseline map[string]int, findings []analyze.Finding, highlightMode int, width, height int) viewport.Model {
+	header := fmt.Sprintf("%s | %d queries | Avg: %.2fs, %.0f rows examined, %.0f sent\n",
 		lipgloss.NewStyle().Bold(true).Render(g.QueryType),
 		g.Count,
 		g.AvgQueryTime,
 		g.AvgRowsExamined,
 		g.AvgRowsSent,
 	)
+	if g.Digest != "" {
+		header += "Digest: " + g.Digest + " | "
+	}
+	header += fmt.Sprintf("Total time: %s (%.1f%% of all slow query time)\n", formatDuration(g.TotalQueryTime), g.ImpactPercent)
+	if len(g.Tables) > 0 {
+		parts := make([]string, len(g.Tables))
+		for i, t := range g.Tables {
+			parts[i] = fmt.Sprintf("%s (%s)", t.String(), t.Role)
+		}
+		header += "Tables: " + strings.Join(parts, ", ") + "\n"
+	}
+	if len(g.Accounts) > 0 {
+		header += "Users: " + formatShares(accountShares(g, func(a types.Account) string { return a.User }), g.TotalQueryTime) + "\n"
+		header += "Hosts: " + formatShares(accountShares(g, func(a types.Account) string { return a.Host }), g.TotalQueryTime) + "\n"
+	}
+	if baseline != nil {
+		switch growth := formatGrowth(g, baseline); growth {
+		case "new":
+			header += growthStyle.Render("New since watching started") + "\n"
+		case "":
+		default:
+			header += growthStyle.Render(fmt.Sprintf("%s executions since watching started (was %d)", growth, baseline[g.Digest])) + "\n"
+		}
+	}
+	header += formatStatsTable(g)
+	if ratio := lockRatio(g); ratio >= lockDominanceRatio {
+		header += lockWarningStyle.Render(fmt.Sprintf("Lock waits account for %.0f%% of this group's query time: likely lock contention", ratio*100)) + "\n"
+	}
+	if g.FirstSeen != "" {
+		header += fmt.Sprintf("First seen: %s | Last seen: %s\n", g.FirstSeen, g.LastSeen)
+	}
+	for _, f := range findings {
+		line := lockWarningStyle.Render(f.Rule.Title)
+		if f.Detail != "" {
+			line += ": " + f.Detail
+		}
+		header += lipgloss.NewStyle().Width(width).Render(line+" — "+f.Rule.Advice) + "\n"
+	}
+	header += trendChart(g.Trend, width, trendChartHeight)
+	header += formatAttributeLines(g, width)
+	if len(g.Examples) < g.Count {
+		header += fmt.Sprintf("Examples: showing %d of %d (random sample, slowest and most recent) | [↵] All kept executions\n", len(g.Examples), g.Count)
+	}
+	header += "\n"
 	var allQueries strings.Builder
 	for i, q := range g.Examples {
 		allQueries.WriteString(q.SQLText)
@@ -38,6 +89,122 @@ func NewPreviewPanel(g types.GroupedQuery, highlightMode int, width, height int)
 	return vp
 }
 
+// maxShares is the number of users or hosts listed in the preview
+const maxShares = 5
+
+// accountShares totals a group's executions per user or host, as picked by key
+func accountShares(g types.GroupedQuery, key func(a types.Account) string) map[string]types.Share {
+	shares := map[string]types.Share{}
+	for a, s := range g.Accounts {
+		total := shares[key(a)]
+		total.Count += s.Count
+		total.Time += s.Time
+		shares[key(a)] = total
+	}
+	return shares
+}
+
+// formatShares lists the values with the most query time first, each with its
+// share of totalTime and its executions: "app 92% (40), report 8% (3)"
+func formatShares(shares map[string]types.Share, totalTime float64) string {
+	names := make([]string, 0, len(shares))
+	for name := range shares {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if shares[names[i]].Time == shares[names[j]].Time {
+			return names[i] < names[j]
+		}
+		return shares[names[i]].Time > shares[names[j]].Time
+	})
+	var parts []string
+	for i, name := range names {
+		if i == maxShares {
+			parts = append(parts, fmt.Sprintf("+%d more", len(names)-maxShares))
+			break
+		}
+		percent := 0.0
+		if totalTime > 0 {
+			percent = shares[name].Time / totalTime * 100
+		}
+		parts = append(parts, fmt.Sprintf("%s %.0f%% (%d)", displayName(name), percent, shares[name].Count))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatStatsTable renders the distribution of the group's metrics, one row per metric
+func formatStatsTable(g types.GroupedQuery) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%-12s", ""))
+	for _, col := range []string{"Min", "Median", "Avg", "P95", "P99", "Max", "StdDev", "Total"} {
+		b.WriteString(fmt.Sprintf("%12s", col))
+	}
+	b.WriteString("\n")
+	formatRows := func(v float64) string { return fmt.Sprintf("%.0f", v) }
+	rows := []struct {
+		label  string
+		stats  types.Stats
+		format func(float64) string
+	}{
+		{"Query time", g.QueryTimeStats, formatDuration},
+		{"Lock time", g.LockTimeStats, formatDuration},
+		{"Rows exam.", g.RowsExaminedStats, formatRows},
+		{"Rows sent", g.RowsSentStats, formatRows},
+	}
+	for _, r := range rows {
+		s := r.stats
+		b.WriteString(fmt.Sprintf("%-12s", r.label))
+		for _, v := range []float64{s.Min, s.Median, s.Avg, s.P95, s.P99, s.Max, s.StdDev, s.Sum} {
+			b.WriteString(fmt.Sprintf("%12s", r.format(v)))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// formatDuration renders seconds with a unit that keeps small lock/query times readable
+func formatDuration(sec float64) string {
+	switch {
+	case sec == 0:
+		return "0"
+	case sec < 0.001:
+		return fmt.Sprintf("%.0fµs", sec*1e6)
+	case sec < 1:
+		return fmt.Sprintf("%.1fms", sec*1e3)
+	}
+	return fmt.Sprintf("%.2fs", sec)
+}
+
+// formatAttributeLines lists a group's extended slow log attributes as "name: value"
+// entries, wrapped to the panel width
+func formatAttributeLines(g types.GroupedQuery, width int) string {
+	if len(g.Attributes) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(g.Attributes))
+	for name := range g.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	lineLen := 0
+	for _, name := range names {
+		part := name + ": " + formatAttr(g.Attributes[name])
+		if lineLen > 0 && lineLen+2+len(part) > width {
+			b.WriteString("\n")
+			lineLen = 0
+		}
+		if lineLen > 0 {
+			b.WriteString("  ")
+			lineLen += 2
+		}
+		b.WriteString(part)
+		lineLen += len(part)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
 // RenderZoomedPreviewView renders the zoomed preview panel
 func RenderZoomedPreviewView(m Model) string {
 	panelWidth := m.viewport.Width
@@ -77,7 +244,22 @@ func RenderMainUIView(m Model) string {
 	tableBox := tableBoxStyle.Width(panelWidth).Render(tableContent)
 	sqlBox := sqlBoxStyle.Width(panelWidth).Render(m.viewport.View())
 
-	helpBox := RenderHelpPanel(int(m.highlightMode), panelWidth, m.statusText, m.statusColor)
+	var info []string
+	if m.filtering {
+		info = append(info, m.filterInput.View())
+	} else {
+		if !m.load.Range.IsZero() {
+			info = append(info, "Range: "+m.load.Range.String())
+		}
+		if m.filter.Active() {
+			info = append(info, fmt.Sprintf("Filter: %s (%d/%d) [Esc] Clear", m.filter.Raw, len(m.filteredGroups), len(m.allGroups)))
+		}
+	}
+	status, statusColor := m.statusText, m.statusColor
+	if m.loader != nil && m.pendingAnalyze == nil && m.inspectCancel == nil {
+		status, statusColor = m.loadProgress(), lipgloss.Color("#00afff")
+	}
+	helpBox := RenderHelpPanel(int(m.highlightMode), panelWidth, status, statusColor, strings.Join(info, "  "), m.filtering)
 
 	return appStyle.Margin(0, 0).Render(
 		tableBox + "\n" + sqlBox + "\n" + helpBox,
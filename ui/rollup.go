@@ -0,0 +1,362 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"slowlog-tui/types"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// rollupKey names a rollup row; filter is the filter term selecting its groups
+type rollupKey struct {
+	name   string
+	filter string
+}
+
+// rollupDimensions are the fields the rollup view groups by, cycled with "v".
+// keys returns the rows a group belongs to with the executions and query time it
+// contributes to each.
+var rollupDimensions = []struct {
+	Name  string
+	Title string // column title
+	keys  func(g types.GroupedQuery) map[rollupKey]types.Share
+}{
+	{"table", "Table", func(g types.GroupedQuery) map[rollupKey]types.Share {
+		// a query class counts in full for every table it references
+		keys := map[rollupKey]types.Share{}
+		for _, t := range g.Tables {
+			keys[rollupKey{t.String(), "table=" + quoteFilterValue(t.String())}] = types.Share{Count: g.Count, Time: g.TotalQueryTime}
+		}
+		return keys
+	}},
+	{"database", "Database", func(g types.GroupedQuery) map[rollupKey]types.Share {
+		keys := map[rollupKey]types.Share{}
+		for name, s := range g.Databases {
+			keys[rollupKey{name, "db:" + filterValue(name)}] = s
+		}
+		return keys
+	}},
+	{"query type", "Type", func(g types.GroupedQuery) map[rollupKey]types.Share {
+		return map[rollupKey]types.Share{
+			{g.QueryType, "type:" + filterValue(g.QueryType)}: {Count: g.Count, Time: g.TotalQueryTime},
+		}
+	}},
+	{"user", "User", func(g types.GroupedQuery) map[rollupKey]types.Share {
+		keys := map[rollupKey]types.Share{}
+		for name, s := range accountShares(g, func(a types.Account) string { return a.User }) {
+			keys[rollupKey{name, "user:" + filterValue(name)}] = s
+		}
+		return keys
+	}},
+	{"client host", "Host", func(g types.GroupedQuery) map[rollupKey]types.Share {
+		keys := map[rollupKey]types.Share{}
+		for name, s := range accountShares(g, func(a types.Account) string { return a.Host }) {
+			keys[rollupKey{name, "host:" + filterValue(name)}] = s
+		}
+		return keys
+	}},
+}
+
+// rollupRow is the total of the query classes behind one table, database or query type
+type rollupRow struct {
+	rollupKey
+	count    int
+	time     float64
+	worstP95 float64
+	groups   []types.GroupedQuery // by total time, most first
+}
+
+// rollupOrders are the orders the rollup list cycles through with "o"
+var rollupOrders = []struct {
+	Name string
+	Less func(a, b rollupRow) bool
+}{
+	{"Total time", func(a, b rollupRow) bool { return a.time > b.time }},
+	{"Count", func(a, b rollupRow) bool { return a.count > b.count }},
+	{"Worst P95", func(a, b rollupRow) bool { return a.worstP95 > b.worstP95 }},
+	{"Classes", func(a, b rollupRow) bool { return len(a.groups) > len(b.groups) }},
+}
+
+// rollupGroups totals groups by one of rollupDimensions
+func rollupGroups(groups []types.GroupedQuery, dimension int) []rollupRow {
+	byKey := map[rollupKey]*rollupRow{}
+	var rows []*rollupRow
+	for _, g := range groups {
+		for key, s := range rollupDimensions[dimension].keys(g) {
+			r := byKey[key]
+			if r == nil {
+				r = &rollupRow{rollupKey: key}
+				byKey[key] = r
+				rows = append(rows, r)
+			}
+			r.count += s.Count
+			r.time += s.Time
+			r.worstP95 = max(r.worstP95, g.QueryTimeStats.P95)
+			r.groups = append(r.groups, g)
+		}
+	}
+	out := make([]rollupRow, len(rows))
+	for i, r := range rows {
+		sort.SliceStable(r.groups, func(i, j int) bool { return r.groups[i].TotalQueryTime > r.groups[j].TotalQueryTime })
+		out[i] = *r
+	}
+	return out
+}
+
+// likeEscaper escapes the LIKE wildcards of a name so filter terms match it exactly
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// filterValue turns a name into a filter value matching exactly that name: LIKE
+// wildcards are escaped and values that contain spaces or are empty are quoted
+func filterValue(s string) string {
+	return quoteFilterValue(likeEscaper.Replace(s))
+}
+
+// quoteFilterValue quotes values that contain spaces or are empty
+func quoteFilterValue(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t") {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+// rollupView is an alternate top level: the groups shown in the main table rolled
+// up by table, database or query type, with the query classes of the selected row
+type rollupView struct {
+	groups     []types.GroupedQuery
+	dimension  int // index into rollupDimensions
+	order      int // index into rollupOrders
+	rows       []rollupRow
+	totalTime  float64
+	table      table.Model
+	detail     viewport.Model
+	lastCursor int
+}
+
+// newRollupView rolls up groups by the given dimension in a panel of the given size
+func newRollupView(groups []types.GroupedQuery, dimension, width, height int) rollupView {
+	v := rollupView{dimension: dimension}
+	v.detail = viewport.New(width, 1)
+	v.resize(width, height)
+	v.setGroups(groups)
+	return v
+}
+
+// resize splits the height like the executions view
+func (v *rollupView) resize(width, height int) {
+	listHeight := max((height-8)/2, 3)
+	v.detail.Width = width
+	v.detail.Height = max(height-8-listHeight, 3)
+	cursor := v.table.Cursor()
+	v.table = newRollupTable(v.rows, v.totalTime, rollupDimensions[v.dimension].Title, width, listHeight)
+	v.table.SetCursor(cursor)
+	v.lastCursor = -1
+	v.updateDetail()
+}
+
+// setGroups recomputes the rows from groups, keeping the selected row
+func (v *rollupView) setGroups(groups []types.GroupedQuery) {
+	selected := ""
+	if i := v.table.Cursor(); i >= 0 && i < len(v.rows) {
+		selected = v.rows[i].name
+	}
+	v.groups = groups
+	v.totalTime = 0
+	for _, g := range groups {
+		v.totalTime += g.TotalQueryTime
+	}
+	v.rows = rollupGroups(groups, v.dimension)
+	less := rollupOrders[v.order].Less
+	sort.SliceStable(v.rows, func(i, j int) bool { return less(v.rows[i], v.rows[j]) })
+	v.table = newRollupTable(v.rows, v.totalTime, rollupDimensions[v.dimension].Title, v.detail.Width, v.table.Height())
+	for i, r := range v.rows {
+		if r.name == selected {
+			v.table.SetCursor(i)
+		}
+	}
+	v.lastCursor = -1
+	v.updateDetail()
+}
+
+// updateDetail lists the query classes of the selected row if the cursor moved
+func (v *rollupView) updateDetail() {
+	i := v.table.Cursor()
+	if i == v.lastCursor {
+		return
+	}
+	v.lastCursor = i
+	if i < 0 || i >= len(v.rows) {
+		v.detail.SetContent("")
+		return
+	}
+	r := v.rows[i]
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s %s | %d query classes | %d queries | Total time: %s | Worst P95: %s\n",
+		lipgloss.NewStyle().Bold(true).Render(rollupDimensions[v.dimension].Title), displayName(r.name),
+		len(r.groups), r.count, formatDuration(r.time), formatDuration(r.worstP95)))
+	b.WriteString("[↵] Show these query classes\n\n")
+	b.WriteString(fmt.Sprintf("%-16s %8s %10s %10s  %-8s %-12s %s\n", "Impact", "Count", "Total", "P95", "Type", "Digest", "Query"))
+	for _, g := range r.groups {
+		line := fmt.Sprintf("%-16s %8d %10s %10s  %-8s %-12s %s",
+			impactBar(g.ImpactPercent), g.Count, formatDuration(g.TotalQueryTime), formatDuration(g.QueryTimeStats.P95),
+			g.QueryType, shortDigest(g.Digest), g.NormalizedSQL)
+		b.WriteString(truncateLine(line, v.detail.Width) + "\n")
+	}
+	v.detail.SetContent(b.String())
+	v.detail.GotoTop()
+}
+
+// displayName shows rows of executions without a database, user or host
+func displayName(name string) string {
+	if name == "" {
+		return "(none)"
+	}
+	return name
+}
+
+// truncateLine cuts a plain text line to width cells
+func truncateLine(s string, width int) string {
+	if r := []rune(s); width > 3 && len(r) > width {
+		return string(r[:width-3]) + "..."
+	}
+	return s
+}
+
+// newRollupTable builds the rollup list; impact is the share of all shown groups' time
+func newRollupTable(rows []rollupRow, totalTime float64, title string, width, height int) table.Model {
+	cols := []table.Column{
+		{Title: "#", Width: 4},
+		{Title: "Impact", Width: 16},
+		{Title: "Classes", Width: 8},
+		{Title: "Count", Width: 9},
+		{Title: "Total Time", Width: 11},
+		{Title: "Worst P95", Width: 10},
+	}
+	used := 0
+	for _, c := range cols {
+		used += c.Width
+	}
+	nameWidth := min(max(width-used-2, 10), 60)
+	cols = append(cols[:1], append([]table.Column{{Title: title, Width: nameWidth}}, cols[1:]...)...)
+
+	tableRows := make([]table.Row, len(rows))
+	for i, r := range rows {
+		impact := 0.0
+		if totalTime > 0 {
+			impact = r.time / totalTime * 100
+		}
+		tableRows[i] = table.Row{
+			fmt.Sprintf("%d", i+1),
+			truncateLine(displayName(r.name), nameWidth),
+			impactBar(impact),
+			fmt.Sprintf("%d", len(r.groups)),
+			fmt.Sprintf("%d", r.count),
+			formatDuration(r.time),
+			formatDuration(r.worstP95),
+		}
+	}
+	tbl := table.New(
+		table.WithColumns(cols),
+		table.WithRows(tableRows),
+		table.WithFocused(true),
+		table.WithHeight(height),
+	)
+	tbl.SetStyles(table.Styles{
+		Selected: selectedRowStyle,
+	})
+	return tbl
+}
+
+// openRollup rolls up the groups shown in the table, starting with the given dimension
+func (m *Model) openRollup(dimension int) {
+	m.rollup = newRollupView(m.filteredGroups, dimension, m.viewport.Width, m.height)
+	m.showRollup = true
+}
+
+// drillDown shows the groups behind the selected rollup row by adding its term
+// to the filter
+func (m *Model) drillDown() {
+	v := m.rollup
+	i := v.table.Cursor()
+	if i < 0 || i >= len(v.rows) {
+		return
+	}
+	expr := v.rows[i].filter
+	if m.filter.Raw != "" {
+		expr = m.filter.Raw + " " + expr
+	}
+	f, err := ParseFilter(expr)
+	if err != nil {
+		m.statusText = err.Error()
+		m.statusColor = lipgloss.Color("#ff5f5f")
+		return
+	}
+	m.showRollup = false
+	m.filter = f
+	m.filterInput.SetValue(expr)
+	m.applyFilters(m.viewport.Width)
+	m.focus = focusTable
+	m.table.Focus()
+	m.updateViewport()
+}
+
+// updateRollup handles keys while the rollup view is open
+func (m Model) updateRollup(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := &m.rollup
+	var cmd tea.Cmd
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+	case "esc", "backspace":
+		m.showRollup = false
+		return m, nil
+	case "enter":
+		m.drillDown()
+		return m, nil
+	case "v":
+		v.dimension = (v.dimension + 1) % len(rollupDimensions)
+		v.table.SetCursor(0)
+		v.setGroups(v.groups)
+		return m, nil
+	case "o":
+		v.order = (v.order + 1) % len(rollupOrders)
+		v.setGroups(v.groups)
+		return m, nil
+	case "pgup", "pgdown", "ctrl+u", "ctrl+d":
+		v.detail, cmd = v.detail.Update(msg)
+		return m, cmd
+	}
+	v.table, cmd = v.table.Update(msg)
+	v.updateDetail()
+	return m, cmd
+}
+
+// RenderRollupView renders the rollup list, the query classes of the selected row and the key help
+func RenderRollupView(m Model) string {
+	v := m.rollup
+	panelWidth := m.viewport.Width
+	title := fmt.Sprintf("%s | %d rows from %d query classes | Order: %s",
+		lipgloss.NewStyle().Bold(true).Render("By "+rollupDimensions[v.dimension].Name), len(v.rows), len(v.groups), rollupOrders[v.order].Name)
+	if m.filter.Active() {
+		title += " | Filter: " + m.filter.Raw
+	}
+	listBox := leftStyle.BorderForeground(activeBorder).Width(panelWidth).Render(title + "\n" + v.table.View())
+	detailBox := rightStyle.BorderForeground(inactiveBorder).Width(panelWidth).Render(v.detail.View())
+	help := "[↑/↓] Select  [↵] Show query classes  [v] Table/DB/Type/User/Host  [o] Order  [PgUp/PgDn] Scroll  [Esc] Back  [q] Quit"
+	status, statusColor := m.statusText, m.statusColor
+	if m.loader != nil {
+		status, statusColor = m.loadProgress(), lipgloss.Color("#00afff")
+	}
+	if status != "" {
+		help += "  " + lipgloss.NewStyle().Foreground(statusColor).Render(status)
+	}
+	helpBox := lipgloss.NewStyle().Border(lipgloss.NormalBorder()).Width(panelWidth).Height(1).Render(ansi.Truncate(help, max(panelWidth-4, 0), "…"))
+	return appStyle.Margin(0, 0).Render(listBox + "\n" + detailBox + "\n" + helpBox)
+}
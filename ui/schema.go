@@ -0,0 +1,209 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"slowlog-tui/db"
+	"slowlog-tui/types"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// schemaTimeout bounds the lookup of a group's table definitions
+const schemaTimeout = 30 * time.Second
+
+// lowSelectivity is the share of distinct values (cardinality / rows) below which
+// a non-unique index is flagged, for tables of at least lowSelectivityMinRows rows
+const (
+	lowSelectivity        = 0.01
+	lowSelectivityMinRows = 1000
+)
+
+// schemaMsg delivers the table definitions of a group
+type schemaMsg struct {
+	digest string
+	gen    int
+	advise bool // show the indexes proposed for the tables instead of their definitions
+	tables []db.TableSchema
+	err    error
+}
+
+// referencedTables returns the tables of g once each, qualified with the database
+// of its slowest kept execution when they are not
+func referencedTables(g types.GroupedQuery) []db.TableName {
+	q, _ := slowestExample(g)
+	var tables []db.TableName
+	seen := map[db.TableName]bool{}
+	for _, t := range g.Tables {
+		name := db.TableName{Schema: t.Schema, Name: t.Name}
+		if name.Schema == "" {
+			name.Schema = q.DB
+		}
+		if !seen[name] {
+			seen[name] = true
+			tables = append(tables, name)
+		}
+	}
+	return tables
+}
+
+// describeSelected looks up the definitions of the tables of the selected group,
+// to show them or, with advise, the indexes they lack
+func (m *Model) describeSelected(advise bool) tea.Cmd {
+	i := m.table.Cursor()
+	if i < 0 || i >= len(m.filteredGroups) {
+		return nil
+	}
+	g := m.filteredGroups[i]
+	fail := func(text string) tea.Cmd {
+		m.statusText = text
+		m.statusColor = lipgloss.Color("#ff5f5f")
+		return flashStatus()
+	}
+	ss, ok := m.source.(db.ServerSource)
+	if !ok {
+		return fail("Table schemas need a server connection (-source slow_log or perfschema)")
+	}
+	tables := referencedTables(g)
+	if len(tables) == 0 {
+		return fail("No tables found in this query")
+	}
+
+	if m.inspectCancel != nil {
+		m.inspectCancel()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), schemaTimeout)
+	m.inspectGen++
+	m.inspectCancel = cancel
+	m.statusText = "Reading table schemas... [Ctrl+C] Cancel"
+	m.statusColor = lipgloss.Color("#00afff")
+
+	server := ss.Server()
+	gen := m.inspectGen
+	return func() tea.Msg {
+		defer cancel()
+		msg := schemaMsg{digest: g.Digest, gen: gen, advise: advise}
+		msg.tables, msg.err = server.DescribeTables(ctx, tables)
+		return msg
+	}
+}
+
+// handleSchema shows the looked up table definitions or index advice in the preview
+func (m Model) handleSchema(msg schemaMsg) (tea.Model, tea.Cmd) {
+	if msg.gen != m.inspectGen {
+		return m, nil // cancelled or superseded
+	}
+	m.inspectCancel = nil
+	if msg.err != nil {
+		m.statusText = "Reading table schemas failed: " + msg.err.Error()
+		m.statusColor = lipgloss.Color("#ff5f5f")
+		return m, nil
+	}
+	for _, g := range m.allGroups {
+		if g.Digest == msg.digest {
+			m.schema = &msg
+			m.explain = nil
+			m.showPreview(g)
+			m.focus = focusPreview
+			m.table.Blur()
+			break
+		}
+	}
+	m.statusText = ""
+	m.statusColor = ""
+	return m, nil
+}
+
+// NewSchemaPanel renders the size, indexes and definition of every table of a
+// group in the preview, flagging indexes of low selectivity
+func NewSchemaPanel(s schemaMsg, g types.GroupedQuery, highlightMode HighlightMode, width, height int) viewport.Model {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s of %s %s | Avg: %.0f rows examined, %.0f sent\n",
+		planTitleStyle.Render("Tables"), g.QueryType, g.Digest, g.AvgRowsExamined, g.AvgRowsSent))
+	b.WriteString("[d] Read again  [Esc] Back to the group\n")
+	for _, t := range s.tables {
+		b.WriteString("\n" + planTitleStyle.Render("── "+t.String()+" ") + "\n")
+		if t.Err != nil {
+			b.WriteString(fullScanStyle.Render("Error: "+t.Err.Error()) + "\n")
+			continue
+		}
+		b.WriteString(fmt.Sprintf("%s | ~%d rows | data %s | indexes %s\n",
+			t.Engine, t.Rows, formatBytes(t.DataLength), formatBytes(t.IndexLength)))
+		b.WriteString(formatIndexes(t))
+		b.WriteString("\n")
+		if highlightMode == HighlightSimple {
+			b.WriteString(HighlightSQL(t.CreateTable))
+		} else {
+			b.WriteString(t.CreateTable)
+		}
+		b.WriteString("\n")
+	}
+	vp := viewport.New(width, height)
+	vp.SetContent(b.String())
+	return vp
+}
+
+// formatIndexes lists the indexes of a table with the cardinality of every column
+// prefix and the selectivity of the whole index
+func formatIndexes(t db.TableSchema) string {
+	if len(t.Indexes) == 0 {
+		return fullScanStyle.Render("No indexes") + "\n"
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%-24s %-7s %-40s %-24s %s\n", "Index", "Unique", "Columns", "Cardinality", "Selectivity"))
+	for _, ix := range t.Indexes {
+		cols := make([]string, len(ix.Columns))
+		cards := make([]string, len(ix.Columns))
+		for i, c := range ix.Columns {
+			cols[i] = c.Name
+			if c.Name == "" {
+				cols[i] = "(expression)"
+			}
+			if c.SubPart > 0 {
+				cols[i] += fmt.Sprintf("(%d)", c.SubPart)
+			}
+			cards[i] = fmt.Sprintf("%d", c.Cardinality)
+		}
+		unique := "no"
+		if ix.Unique {
+			unique = "yes"
+		}
+		name := ix.Name
+		if ix.Type != "BTREE" && ix.Type != "" {
+			name += " (" + strings.ToLower(ix.Type) + ")"
+		}
+		selectivity := "-"
+		low := false
+		// cardinality is only meaningful for B-tree indexes with statistics
+		if t.Rows > 0 && ix.Cardinality() > 0 && (ix.Type == "BTREE" || ix.Type == "") {
+			ratio := min(float64(ix.Cardinality())/float64(t.Rows), 1)
+			selectivity = fmt.Sprintf("%.2f%%", ratio*100)
+			low = !ix.Unique && ratio < lowSelectivity && t.Rows >= lowSelectivityMinRows
+		}
+		line := fmt.Sprintf("%-24s %-7s %-40s %-24s %s", name, unique, strings.Join(cols, ", "), strings.Join(cards, ", "), selectivity)
+		if low {
+			line = planWarnStyle.Render(line + "  low selectivity")
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// formatBytes renders a size with a binary unit
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -2,61 +2,223 @@ package ui
 
 import (
 	"fmt"
+	"math"
 	"sort"
+	"strings"
+	"unicode/utf8"
 
+	"slowlog-tui/analyze"
 	"slowlog-tui/types"
 
 	"github.com/charmbracelet/bubbles/table"
 )
 
+// attributeColumns lists the extended slow log attributes (Percona/MariaDB) that
+// get a table column and sort entry when present, with their short column titles
+var attributeColumns = []struct{ Name, Title string }{
+	{"Bytes_sent", "Bytes"},
+	{"Tmp_tables", "Tmp"},
+	{"Tmp_disk_tables", "TmpDisk"},
+	{"Full_scan", "FullScan"},
+	{"Filesort", "Filesort"},
+	{"Filesort_on_disk", "FsDisk"},
+	{"InnoDB_IO_r_ops", "IO rOps"},
+	{"InnoDB_rec_lock_wait", "RecLock"},
+	{"QC_hit", "QC Hit"},
+	{"No_index_used", "NoIndex"},
+}
+
+// PresentAttributes returns the names of attributeColumns that any group reports
+func PresentAttributes(groups []types.GroupedQuery) []string {
+	var names []string
+	for _, col := range attributeColumns {
+		for _, g := range groups {
+			if _, ok := g.Attributes[col.Name]; ok {
+				names = append(names, col.Name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+// attributeTitle returns the short column title of an attribute
+func attributeTitle(name string) string {
+	for _, col := range attributeColumns {
+		if col.Name == name {
+			return col.Title
+		}
+	}
+	return name
+}
+
+// formatAttr renders an aggregated attribute: percentage of executions for flags, average otherwise
+func formatAttr(a types.AttrStat) string {
+	if a.Count == 0 {
+		return ""
+	}
+	avg := a.Avg()
+	if a.Flag {
+		return fmt.Sprintf("%.0f%%", avg*100)
+	}
+	if avg < 100 && avg != math.Trunc(avg) {
+		return fmt.Sprintf("%.3f", avg)
+	}
+	return fmt.Sprintf("%.0f", avg)
+}
+
+// shortDigest abbreviates a group digest for the table, like a short git hash
+func shortDigest(digest string) string {
+	if len(digest) > 10 {
+		return digest[:10]
+	}
+	return digest
+}
+
+// formatTables lists the names of the referenced tables, each once
+func formatTables(tables []types.TableRef) string {
+	var names []string
+	seen := map[string]bool{}
+	for _, t := range tables {
+		if name := t.String(); !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// lockDominanceRatio is the share of query time spent waiting for locks above
+// which a group is flagged as a contention problem
+const lockDominanceRatio = 0.5
+
+// lockRatio returns the fraction of the group's total query time spent waiting for locks
+func lockRatio(g types.GroupedQuery) float64 {
+	if g.QueryTimeStats.Sum <= 0 {
+		return 0
+	}
+	return g.LockTimeStats.Sum / g.QueryTimeStats.Sum
+}
+
+// formatLock renders the average lock time, prefixed with "!" when lock waits dominate
+func formatLock(g types.GroupedQuery) string {
+	lock := formatDuration(g.LockTimeStats.Avg)
+	if lockRatio(g) >= lockDominanceRatio {
+		return "! " + lock
+	}
+	return lock
+}
+
+// impactBarWidth is the number of cells of the impact bar at 100%
+const impactBarWidth = 8
+
+// impactBar renders a group's share of total query time as a bar plus percentage
+func impactBar(percent float64) string {
+	eighths := int(percent/100*impactBarWidth*8 + 0.5)
+	partials := []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+	bar := strings.Repeat("█", eighths/8) + partials[eighths%8]
+	bar += strings.Repeat(" ", impactBarWidth-utf8.RuneCountInString(bar))
+	return fmt.Sprintf("%s %5.1f%%", bar, percent)
+}
+
+// formatGrowth renders how a group changed since watch mode caught up: "new" for
+// groups that did not exist then, "+n" for groups that gained n executions
+func formatGrowth(g types.GroupedQuery, baseline map[string]int) string {
+	before, ok := baseline[g.Digest]
+	switch {
+	case !ok:
+		return "new"
+	case g.Count > before:
+		return fmt.Sprintf("+%d", g.Count-before)
+	}
+	return ""
+}
+
+// formatBadges lists the badges of a group's anti-patterns
+func formatBadges(findings []analyze.Finding) string {
+	badges := make([]string, len(findings))
+	for i, f := range findings {
+		badges[i] = f.Rule.Badge
+	}
+	return strings.Join(badges, " ")
+}
+
 // TablePanel handles the grouped queries table logic
-// It is stateless; state is managed by the main Model
-func NewTablePanel(filteredGroups []types.GroupedQuery, tableWidth, tableHeight int) table.Model {
+// It is stateless; state is managed by the main Model. baseline holds the
+// execution counts per digest when watch mode caught up, nil when not watching.
+// findings holds the anti-patterns per digest.
+func NewTablePanel(filteredGroups []types.GroupedQuery, attrs []string, baseline map[string]int, findings map[string][]analyze.Finding, tableWidth, tableHeight int) table.Model {
+	cols := []table.Column{
+		{Title: "#", Width: 4},
+		{Title: "Digest", Width: 12},
+		{Title: "Type", Width: 8},
+		{Title: "DB", Width: 24},
+		{Title: "Table", Width: 26},
+		{Title: "Lint", Width: 14},
+		{Title: "Impact", Width: 16},
+		{Title: "Trend", Width: sparklineWidth + 1},
+		{Title: "Count", Width: 8},
+		{Title: "Avg Time", Width: 10},
+		{Title: "Lock", Width: 10},
+		{Title: "Avg Examined", Width: 13},
+		{Title: "Avg Sent", Width: 10},
+	}
+	if baseline != nil {
+		cols = append(cols[:1], append([]table.Column{{Title: "Δ Watch", Width: 8}}, cols[1:]...)...)
+	}
+	for _, name := range attrs {
+		title := attributeTitle(name)
+		cols = append(cols, table.Column{Title: title, Width: max(len(title)+1, 6)})
+	}
+	minOtherCols := 0
+	for _, c := range cols {
+		minOtherCols += c.Width
+	}
+	maxShortQuery := tableWidth - minOtherCols
+	if maxShortQuery > 50 {
+		maxShortQuery = 50
+	}
+	if maxShortQuery < 10 {
+		maxShortQuery = 10
+	}
+	cols = append(cols, table.Column{Title: "Query", Width: maxShortQuery})
+
 	var rows []table.Row
 	for i, g := range filteredGroups {
 		db := ""
 		if len(g.Examples) > 0 {
 			db = g.Examples[0].DB
 		}
-		tableName := g.FromTable
-		minOtherCols := 4 + 8 + 24 + 16 + 8 + 10 + 12 + 10 + 8
-		maxShortQuery := tableWidth - minOtherCols
-		if maxShortQuery > 50 {
-			maxShortQuery = 50
-		}
-		if maxShortQuery < 10 {
-			maxShortQuery = 10
-		}
+		tableName := formatTables(g.Tables)
 		shortQuery := g.NormalizedSQL
 		if len(shortQuery) > maxShortQuery {
 			shortQuery = shortQuery[:maxShortQuery-3] + "..."
 		}
 		row := table.Row{
 			fmt.Sprintf("%d", i+1),
+			shortDigest(g.Digest),
 			g.QueryType,
 			db,
 			tableName,
+			formatBadges(findings[g.Digest]),
+			impactBar(g.ImpactPercent),
+			sparkline(g.Trend, sparklineWidth),
 			fmt.Sprintf("%d", g.Count),
 			fmt.Sprintf("%.2fs", g.AvgQueryTime),
+			formatLock(g),
 			fmt.Sprintf("%.0f", g.AvgRowsExamined),
 			fmt.Sprintf("%.0f", g.AvgRowsSent),
-			shortQuery,
 		}
+		if baseline != nil {
+			row = append(row[:1], append(table.Row{formatGrowth(g, baseline)}, row[1:]...)...)
+		}
+		for _, name := range attrs {
+			row = append(row, formatAttr(g.Attributes[name]))
+		}
+		row = append(row, shortQuery)
 		rows = append(rows, row)
 	}
 
-	cols := []table.Column{
-		{Title: "#", Width: 4},
-		{Title: "Type", Width: 8},
-		{Title: "DB", Width: 24},
-		{Title: "Table", Width: 26},
-		{Title: "Count", Width: 8},
-		{Title: "Avg Time", Width: 10},
-		{Title: "Avg Examined", Width: 12},
-		{Title: "Avg Sent", Width: 10},
-		{Title: "Query", Width: 50},
-	}
-
 	tbl := table.New(
 		table.WithColumns(cols),
 		table.WithRows(rows),
@@ -109,9 +271,68 @@ func SortGroups(groups []types.GroupedQuery, sortColumn, sortOrder int) {
 		}
 	case 6: // Table
 		less = func(i, j int) bool {
-			return groups[i].FromTable < groups[j].FromTable
+			return formatTables(groups[i].Tables) < formatTables(groups[j].Tables)
+		}
+	case 7: // Median Time
+		less = func(i, j int) bool {
+			return groups[i].QueryTimeStats.Median > groups[j].QueryTimeStats.Median
+		}
+	case 8: // P95 Time
+		less = func(i, j int) bool {
+			return groups[i].QueryTimeStats.P95 > groups[j].QueryTimeStats.P95
 		}
+	case 9: // P99 Time
+		less = func(i, j int) bool {
+			return groups[i].QueryTimeStats.P99 > groups[j].QueryTimeStats.P99
+		}
+	case 10: // Max Time
+		less = func(i, j int) bool {
+			return groups[i].QueryTimeStats.Max > groups[j].QueryTimeStats.Max
+		}
+	case 11: // StdDev Time
+		less = func(i, j int) bool {
+			return groups[i].QueryTimeStats.StdDev > groups[j].QueryTimeStats.StdDev
+		}
+	case 12: // P95 Examined
+		less = func(i, j int) bool {
+			return groups[i].RowsExaminedStats.P95 > groups[j].RowsExaminedStats.P95
+		}
+	case 13: // P95 Sent
+		less = func(i, j int) bool {
+			return groups[i].RowsSentStats.P95 > groups[j].RowsSentStats.P95
+		}
+	case 14: // Avg Lock
+		less = func(i, j int) bool {
+			return groups[i].LockTimeStats.Avg > groups[j].LockTimeStats.Avg
+		}
+	case 15: // Max Lock
+		less = func(i, j int) bool {
+			return groups[i].LockTimeStats.Max > groups[j].LockTimeStats.Max
+		}
+	case 16: // Total Lock
+		less = func(i, j int) bool {
+			return groups[i].LockTimeStats.Sum > groups[j].LockTimeStats.Sum
+		}
+	case 17: // Impact
+		less = func(i, j int) bool {
+			if groups[i].TotalQueryTime == groups[j].TotalQueryTime {
+				return groups[i].Count > groups[j].Count
+			}
+			return groups[i].TotalQueryTime > groups[j].TotalQueryTime
+		}
+	}
+	sortWith(groups, less, sortOrder)
+}
+
+// SortGroupsByAttribute sorts groups in-place by the average of an extended attribute
+func SortGroupsByAttribute(groups []types.GroupedQuery, name string, sortOrder int) {
+	less := func(i, j int) bool {
+		return groups[i].Attributes[name].Avg() > groups[j].Attributes[name].Avg()
 	}
+	sortWith(groups, less, sortOrder)
+}
+
+func sortWith(groups []types.GroupedQuery, less func(i, j int) bool, sortOrder int) {
 	if sortOrder == 0 {
 		sort.Slice(groups, less)
 	} else {
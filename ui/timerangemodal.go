@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"slowlog-tui/types"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// timeRangePresets are offered in the modal; each one fills Since and clears Until
+var timeRangePresets = []struct{ Key, Since string }{
+	{"1", "15m"},
+	{"2", "1h"},
+	{"3", "6h"},
+	{"4", "24h"},
+	{"5", "7d"},
+	{"0", ""},
+}
+
+// newTimeInputs returns the Since/Until inputs of the time range modal, prefilled with tr
+func newTimeInputs(tr types.TimeRange) [2]textinput.Model {
+	var inputs [2]textinput.Model
+	for i, bound := range []time.Time{tr.Since, tr.Until} {
+		in := textinput.New()
+		in.Prompt = ""
+		in.Placeholder = "2h, 7d, 2024-01-02 15:04"
+		in.CharLimit = 32
+		in.Width = 24
+		if !bound.IsZero() {
+			in.SetValue(bound.Format("2006-01-02 15:04:05"))
+		}
+		inputs[i] = in
+	}
+	inputs[0].Focus()
+	return inputs
+}
+
+// openTimeModal shows the time range picker for the current range
+func (m *Model) openTimeModal() tea.Cmd {
+	m.showTimeModal = true
+	m.timeFocus = 0
+	m.timeInputs = newTimeInputs(m.load.Range)
+	m.timeError = ""
+	return textinput.Blink
+}
+
+// updateTimeModal handles keys while the time range picker is open
+func (m Model) updateTimeModal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.showTimeModal = false
+		return m, nil
+	case "tab", "shift+tab", "up", "down":
+		m.timeInputs[m.timeFocus].Blur()
+		m.timeFocus = 1 - m.timeFocus
+		return m, m.timeInputs[m.timeFocus].Focus()
+	case "enter":
+		now := time.Now()
+		var tr types.TimeRange
+		var err error
+		if tr.Since, err = types.ParseTimeBound(m.timeInputs[0].Value(), now); err != nil {
+			m.timeError = "Since: " + err.Error()
+			return m, nil
+		}
+		if tr.Until, err = types.ParseTimeBound(m.timeInputs[1].Value(), now); err != nil {
+			m.timeError = "Until: " + err.Error()
+			return m, nil
+		}
+		if !tr.Since.IsZero() && !tr.Until.IsZero() && !tr.Since.Before(tr.Until) {
+			m.timeError = "Since must be before Until"
+			return m, nil
+		}
+		m.showTimeModal = false
+		m.statusText = ""
+		opts := m.load
+		opts.Range = tr
+		return m, m.startLoad(opts)
+	}
+	for _, p := range timeRangePresets {
+		if msg.String() == "alt+"+p.Key {
+			m.timeInputs[0].SetValue(p.Since)
+			m.timeInputs[1].SetValue("")
+			m.timeError = ""
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	m.timeInputs[m.timeFocus], cmd = m.timeInputs[m.timeFocus].Update(msg)
+	m.timeError = ""
+	return m, cmd
+}
+
+// RenderTimeRangeModalView renders the time range picker over the main view
+func RenderTimeRangeModalView(m Model) string {
+	modalWidth := 60
+	var b strings.Builder
+	b.WriteString("Time range (re-reads " + m.source.Name() + "):\n\n")
+	for i, label := range []string{"Since", "Until"} {
+		cursor := "  "
+		if m.timeFocus == i {
+			cursor = "▶ "
+		}
+		b.WriteString(fmt.Sprintf("%s%-6s %s\n", cursor, label, m.timeInputs[i].View()))
+	}
+	b.WriteString("\nEmpty = open bound. Relative times count back from now.\n")
+	presets := make([]string, len(timeRangePresets))
+	for i, p := range timeRangePresets {
+		since := p.Since
+		if since == "" {
+			since = "all"
+		}
+		presets[i] = fmt.Sprintf("[Alt+%s] %s", p.Key, since)
+	}
+	b.WriteString(strings.Join(presets, " ") + "\n")
+	if m.timeError != "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#ff5f5f")).Render(m.timeError))
+	}
+	b.WriteString("\n[Tab] Switch  [Enter] Apply  [Esc] Cancel")
+
+	modal := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Width(modalWidth).Padding(0, 1).Render(b.String())
+	padTop := max((m.height-lipgloss.Height(modal))/2, 0)
+	padLeft := max((m.viewport.Width-modalWidth)/2, 0)
+	return strings.Repeat("\n", padTop) + lipgloss.NewStyle().MarginLeft(padLeft).Render(modal)
+}
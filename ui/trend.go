@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"slowlog-tui/types"
+)
+
+// sparklineWidth is the number of cells of the table's trend column
+const sparklineWidth = 12
+
+// trendChartHeight is the number of rows of the preview's trend chart
+const trendChartHeight = 6
+
+// maxTrendCellWidth is the most columns one bucket takes in the trend chart
+const maxTrendCellWidth = 3
+
+// sparkLevels are the block characters for 1/8 to 8/8 of a cell
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// resample sums consecutive buckets so that the values fit into width cells
+func resample(values []float64, width int) []float64 {
+	if len(values) <= width {
+		return values
+	}
+	cells := make([]float64, width)
+	for i, v := range values {
+		cells[i*width/len(values)] += v
+	}
+	return cells
+}
+
+// trendValues returns the per-bucket execution counts of a trend as floats
+func trendValues(tr types.Trend) []float64 {
+	values := make([]float64, len(tr.Counts))
+	for i, c := range tr.Counts {
+		values[i] = float64(c)
+	}
+	return values
+}
+
+func maxValue(values []float64) float64 {
+	var m float64
+	for _, v := range values {
+		m = max(m, v)
+	}
+	return m
+}
+
+// sparkline renders the executions of a trend as a one-line bar chart, scaled to
+// the group's busiest cell; empty cells stay blank so spikes stand out
+func sparkline(tr types.Trend, width int) string {
+	if len(tr.Counts) == 0 {
+		return ""
+	}
+	cells := resample(trendValues(tr), width)
+	peak := maxValue(cells)
+	var b strings.Builder
+	for _, v := range cells {
+		if v == 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		level := int(v / peak * float64(len(sparkLevels)-1))
+		b.WriteRune(sparkLevels[level])
+	}
+	return b.String()
+}
+
+// formatBucket renders a bucket width as "1m", "15m", "6h", "1d"
+func formatBucket(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return fmt.Sprintf("%dm", d/time.Minute)
+}
+
+// trendChart renders the executions of a trend as a bar chart of the given size,
+// with the peak on the y axis and the time range below it
+func trendChart(tr types.Trend, width, height int) string {
+	if len(tr.Counts) == 0 {
+		return ""
+	}
+	values := trendValues(tr)
+	peak := maxValue(values)
+	if peak == 0 {
+		return ""
+	}
+	axis := fmt.Sprintf("%.0f", peak)
+	labelWidth := len(axis) + 1
+	cells := resample(values, max(width-labelWidth-1, 1))
+	perCell := float64(len(values)) / float64(len(cells))
+	if len(cells) < len(values) {
+		peak = maxValue(cells)
+		axis = fmt.Sprintf("%.0f", peak)
+	}
+	// widen short trends, up to maxTrendCellWidth columns per bucket
+	if stretch := min((width-labelWidth-1)/len(cells), maxTrendCellWidth); stretch > 1 {
+		wide := make([]float64, 0, len(cells)*stretch)
+		for _, v := range cells {
+			for range stretch {
+				wide = append(wide, v)
+			}
+		}
+		cells = wide
+	}
+
+	var b strings.Builder
+	unit := formatBucket(tr.Bucket)
+	if perCell > 1 {
+		unit = formatBucket(time.Duration(perCell * float64(tr.Bucket)).Round(tr.Bucket))
+	}
+	b.WriteString(fmt.Sprintf("Executions per %s:\n", unit))
+	for row := height - 1; row >= 0; row-- {
+		label := ""
+		if row == height-1 {
+			label = axis
+		} else if row == 0 {
+			label = "0"
+		}
+		b.WriteString(fmt.Sprintf("%*s│", labelWidth, label))
+		for _, v := range cells {
+			// eighths of the whole chart height filled by this cell
+			filled := int(v/peak*float64(height*8) + 0.5)
+			if v > 0 && filled == 0 {
+				filled = 1
+			}
+			switch rest := filled - row*8; {
+			case rest >= 8:
+				b.WriteRune('█')
+			case rest > 0:
+				b.WriteRune(sparkLevels[rest-1])
+			default:
+				b.WriteRune(' ')
+			}
+		}
+		b.WriteString("\n")
+	}
+	start := tr.Start.Format("2006-01-02 15:04")
+	end := tr.End().Format("2006-01-02 15:04")
+	gap := max(len(cells)-len(start)-len(end), 1)
+	b.WriteString(fmt.Sprintf("%*s %s%s%s\n", labelWidth, "", start, strings.Repeat(" ", gap), end))
+	return b.String()
+}